@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"go-backend/internal/app"
+	"go-backend/internal/clock"
 	"go-backend/internal/config"
 	"go-backend/internal/database"
 	"go-backend/internal/logger"
-	"go-backend/internal/middleware"
-	"go-backend/internal/router"
+	"go-backend/internal/tracing"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 func main() {
@@ -18,26 +27,136 @@ func main() {
 	}
 
 	// Initialize logger
-	logger.InitLogger(&cfg.Logging)
-	logger.Info("Starting application...")
+	log := logger.New(&cfg.Logging)
+	clk := clock.New()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, log, clk, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		os.Exit(runRole(cfg, log, clk, os.Args[2:]))
+	}
+
+	// Returning instead of calling log.Fatal lets the deferred cleanup in
+	// run, such as closing the database, take place.
+	if err := run(cfg, log, clk); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config.Config, log *logrus.Logger, clk clock.Clock) error {
+	log.Info("Starting application...")
 
 	// Initialize database
-	if err := database.InitDatabase(&cfg.Database); err != nil {
-		logger.Fatal("Failed to initialize database: ", err)
+	var db *database.DB
+	if cfg.Database.Driver == database.DriverMemory {
+		log.Warn("Using in-memory storage, data is lost on exit")
+	} else {
+		var err error
+		db, err = database.Open(&cfg.Database)
+		if err != nil {
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+		defer func() {
+			db.Close()
+			log.Info("Database connections closed")
+		}()
+		log.Info("Database connected successfully (", cfg.Database.Driver, ")")
+
+		if err := prepareSchema(context.Background(), &cfg.Database, db, log, clk); err != nil {
+			return fmt.Errorf("refusing to start: %w", err)
+		}
+	}
+
+	// Initialize tracing
+	tr, err := tracing.New(context.Background(), &cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := tr.Shutdown(shutdownCtx); err != nil {
+			log.Error("Failed to flush traces: ", err)
+		}
+	}()
+	log.AddHook(tracing.LogHook{})
+
+	// Wire the application
+	application, err := app.New(cfg, db, log, clk, tr)
+	if err != nil {
+		return fmt.Errorf("failed to initialize application: %w", err)
 	}
-	defer database.CloseDatabase()
 
-	// Setup routes
-	r := router.SetupRoutes()
+	server := newHTTPServer(&cfg.Server, application.Handler())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Apply CORS middleware
-	handler := middleware.CORSMiddleware()(r)
+	jobsDone := make(chan struct{})
+	go func() {
+		application.RunJobs(ctx)
+		close(jobsDone)
+	}()
 
 	// Start server
-	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
-	logger.Info("Server starting on ", addr)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("Server starting on ", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		// The jobs still use the database, which is closed on return.
+		stop()
+		<-jobsDone
+		return fmt.Errorf("server failed to start: %w", err)
+	case <-ctx.Done():
+		stop()
+	}
+
+	// Fail readiness first so the instance is taken out of rotation while it
+	// still serves requests.
+	application.BeginShutdown()
+	if cfg.Server.ShutdownDelay > 0 {
+		log.Info("Reporting not ready for ", cfg.Server.ShutdownDelay, " before shutting down")
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+
+	// Stop accepting connections and wait for in-flight requests to finish.
+	log.Info("Shutting down, draining in-flight requests for up to ", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Info("Server stopped")
+
+	// Background work still uses the database, which is closed on return.
+	<-jobsDone
+	if err := application.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("background work did not finish: %w", err)
+	}
+
+	log.Info("Background work stopped")
+	return nil
+}
 
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		logger.Fatal("Server failed to start: ", err)
+func newHTTPServer(cfg *config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-backend/internal/clock"
+	"go-backend/internal/config"
+	"go-backend/internal/database"
+	"go-backend/internal/migration"
+	"os"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [N]    roll back the last N migrations (default 1)
+  status      list migrations and whether they are applied`
+
+// runMigrate implements the "migrate" subcommand and returns the process exit
+// code.
+func runMigrate(cfg *config.Config, log *logrus.Logger, clk clock.Clock, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if cfg.Database.Driver == database.DriverMemory {
+		fmt.Fprintln(os.Stderr, "the memory driver has no schema to migrate")
+		return 1
+	}
+
+	db, err := database.Open(&cfg.Database)
+	if err != nil {
+		log.Error("Failed to initialize database: ", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	migrator, err := migration.NewMigrator(db, clk)
+	if err != nil {
+		log.Error("Failed to load migrations: ", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Error(err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, m := range rolledBack {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Error(err)
+			return 1
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Error(err)
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Unknown:
+				state = "unknown"
+			case s.Modified:
+				state = "modified"
+			case s.Applied:
+				state = "applied"
+			}
+			appliedAt := ""
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-8s %04d_%-30s %s\n", state, s.Version, s.Name, appliedAt)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
+
+// prepareSchema makes sure the schema matches the embedded migrations before
+// the server starts, applying them first when auto_migrate is enabled.
+func prepareSchema(ctx context.Context, cfg *config.DatabaseConfig, db *database.DB, log *logrus.Logger, clk clock.Clock) error {
+	migrator, err := migration.NewMigrator(db, clk)
+	if err != nil {
+		return err
+	}
+
+	if cfg.AutoMigrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			log.Infof("Applied migration %04d_%s", m.Version, m.Name)
+		}
+	}
+
+	if err := migrator.Check(ctx); err != nil {
+		return fmt.Errorf("%w, run \"migrate up\" first", err)
+	}
+	return nil
+}
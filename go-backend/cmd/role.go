@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-backend/internal/clock"
+	"go-backend/internal/config"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/service"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+const roleUsage = `usage: main role <username> <role>
+
+assigns role to the user, for example to make the first admin`
+
+// runRole implements the "role" subcommand and returns the process exit
+// code. Unlike the admin API it can change the role of any user, so it is
+// how the first admin is made.
+func runRole(cfg *config.Config, log *logrus.Logger, clk clock.Clock, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, roleUsage)
+		return 2
+	}
+
+	if cfg.Database.Driver == database.DriverMemory {
+		fmt.Fprintln(os.Stderr, "the memory driver keeps no users between runs")
+		return 1
+	}
+
+	db, err := database.Open(&cfg.Database)
+	if err != nil {
+		log.Error("Failed to initialize database: ", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := prepareSchema(ctx, &cfg.Database, db, log, clk); err != nil {
+		log.Error(err)
+		return 1
+	}
+
+	repos := repository.NewRepositories(db)
+	events := service.NewSecurityEventService(repos.Event, clk)
+	username, name := args[0], args[1]
+	err = repos.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := repos.User.FindByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %q does not exist", username)
+		}
+		role, err := repos.Role.FindByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return fmt.Errorf("role %q does not exist", name)
+		}
+		if err := repos.User.UpdateRole(ctx, user.Username, role.Name); err != nil {
+			return err
+		}
+
+		return events.Record(ctx, &models.SecurityEvent{
+			Username:  user.Username,
+			Type:      models.SecurityEventRoleChanged,
+			UserAgent: "cli",
+		})
+	})
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+
+	fmt.Printf("%s has role %s\n", username, name)
+	return 0
+}
@@ -0,0 +1,305 @@
+// Package app wires the repositories, services, handlers and middleware of
+// one server instance from explicitly provided dependencies. Nothing in here
+// touches package-level state, so several instances can run side by side in
+// one process.
+package app
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/clock"
+	"go-backend/internal/config"
+	"go-backend/internal/database"
+	"go-backend/internal/handler"
+	"go-backend/internal/logger"
+	"go-backend/internal/mail"
+	"go-backend/internal/metrics"
+	"go-backend/internal/middleware"
+	"go-backend/internal/migration"
+	"go-backend/internal/password"
+	"go-backend/internal/repository"
+	"go-backend/internal/repository/memory"
+	"go-backend/internal/router"
+	"go-backend/internal/service"
+	"go-backend/internal/tracing"
+	"go-backend/internal/utils"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type App struct {
+	Config       *config.Config
+	DB           *database.DB
+	Log          *logrus.Logger
+	Clock        clock.Clock
+	Repositories *repository.Repositories
+	// Metrics is nil when metrics are disabled.
+	Metrics *metrics.Metrics
+
+	handler      http.Handler
+	deletions    service.AccountDeletionService
+	exports      service.DataExportService
+	shuttingDown atomic.Bool
+}
+
+// New builds an App. db may be nil when the memory driver is configured, in
+// which case the in-memory repositories are used.
+func New(cfg *config.Config, db *database.DB, log *logrus.Logger, clk clock.Clock, tr *tracing.Tracing) (*App, error) {
+	if cfg == nil || log == nil || clk == nil || tr == nil {
+		return nil, errors.New("app: config, logger, clock and tracing are required")
+	}
+
+	var repos *repository.Repositories
+	var migrator *migration.Migrator
+	if cfg.Database.Driver == database.DriverMemory {
+		repos = memory.NewRepositories()
+	} else {
+		if db == nil {
+			return nil, errors.New("app: a database is required for driver " + cfg.Database.Driver)
+		}
+		repos = repository.NewRepositories(db.WithTracer(tr.Provider.Tracer("go-backend/internal/database")))
+
+		var err error
+		migrator, err = migration.NewMigrator(db, clk)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a := &App{
+		Config:       cfg,
+		DB:           db,
+		Log:          log,
+		Clock:        clk,
+		Repositories: repos,
+	}
+
+	if cfg.Metrics.Enabled {
+		a.Metrics = metrics.New()
+		if db != nil {
+			a.Metrics.RegisterDB(db.DB, cfg.Database.Driver)
+		}
+	}
+
+	// Services
+	tracer := tr.Provider.Tracer("go-backend/internal/service")
+	sessions := service.SessionPolicy{
+		TTL:                  cfg.Auth.SessionTTL,
+		IdleTimeout:          cfg.Auth.SessionIdleTimeout,
+		RenewInterval:        cfg.Auth.SessionRenewInterval,
+		RequireVerifiedEmail: cfg.Auth.Email.RequireVerification,
+	}
+	sessionService := service.NewTracedSessionService(service.NewSessionService(repos.Session, clk, sessions), tracer)
+
+	// The auth mode decides how a login is continued
+	var starter service.SessionStarter = sessionService
+	var authenticator service.Authenticator = sessionService
+	var tokenHandler *handler.TokenHandler
+	switch cfg.Auth.Mode {
+	case auth.ModeSession:
+	case auth.ModeJWT:
+		keys, err := auth.NewKeySet(cfg.Auth.JWT.SigningKey, cfg.Auth.JWT.Keys)
+		if err != nil {
+			return nil, err
+		}
+		tokenService := service.NewTracedTokenService(service.NewTokenService(repos.Session, repos.Refresh, repos.Tx, keys, clk, sessions, service.TokenPolicy{
+			Issuer:     cfg.Auth.JWT.Issuer,
+			AccessTTL:  cfg.Auth.JWT.AccessTTL,
+			RefreshTTL: cfg.Auth.JWT.RefreshTTL,
+		}), tracer)
+		starter = tokenService
+		authenticator = tokenService
+		tokenHandler = handler.NewTokenHandler(tokenService, keys)
+	default:
+		return nil, errors.New("app: unknown auth mode " + cfg.Auth.Mode)
+	}
+
+	apiKeyService := service.NewTracedAPIKeyService(service.NewAPIKeyService(repos.APIKey, clk, cfg.Auth.SessionRenewInterval), tracer)
+	securityEventService := service.NewTracedSecurityEventService(service.NewSecurityEventService(repos.Event, clk), tracer)
+	loginThrottle := service.NewTracedLoginThrottle(service.NewLoginThrottle(repos.Attempt, repos.Tx, securityEventService, clk, service.LockoutPolicy{
+		MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+		IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+		BaseDuration:  cfg.Auth.Lockout.BaseDuration,
+		MaxDuration:   cfg.Auth.Lockout.MaxDuration,
+		ResetAfter:    cfg.Auth.Lockout.ResetAfter,
+	}), tracer)
+	hasher, err := password.NewHasher(cfg.Auth.Password.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	twoFactorService := service.NewTracedTwoFactorService(service.NewTwoFactorService(repos.User, repos.TOTP, repos.Challenge, repos.Tx, starter, loginThrottle, hasher, clk, service.TwoFactorPolicy{
+		Issuer:       cfg.Auth.TwoFactor.Issuer,
+		ChallengeTTL: cfg.Auth.TwoFactor.ChallengeTTL,
+		MaxAttempts:  cfg.Auth.TwoFactor.MaxAttempts,
+	}, a.Metrics), tracer)
+	passwordPolicy := service.PasswordPolicy{
+		Policy: password.Policy{
+			MinLength:        cfg.Auth.Password.MinLength,
+			RequireLowercase: cfg.Auth.Password.RequireLowercase,
+			RequireUppercase: cfg.Auth.Password.RequireUppercase,
+			RequireDigit:     cfg.Auth.Password.RequireDigit,
+			RequireSymbol:    cfg.Auth.Password.RequireSymbol,
+			DisallowUsername: cfg.Auth.Password.DisallowUsername,
+		},
+		HistorySize: cfg.Auth.Password.HistorySize,
+	}
+	if cfg.Auth.Password.BreachedFile != "" {
+		corpus, err := password.LoadCorpus(cfg.Auth.Password.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		passwordPolicy.Breached = corpus
+	}
+	passwordService := service.NewTracedPasswordService(service.NewPasswordService(repos.User, repos.Session, repos.Passwords, repos.Tx, securityEventService, loginThrottle, hasher, clk, passwordPolicy), tracer)
+	mailer, err := mail.New(&cfg.Mail, log, clk)
+	if err != nil {
+		return nil, err
+	}
+	emailPolicy := service.EmailPolicy{
+		VerificationTTL: cfg.Auth.Email.VerificationTTL,
+		ResetTTL:        cfg.Auth.Email.ResetTTL,
+		ResendInterval:  cfg.Auth.Email.ResendInterval,
+		LinkBaseURL:     cfg.Mail.LinkBaseURL,
+	}
+	verificationService := service.NewTracedEmailVerificationService(service.NewEmailVerificationService(repos.User, repos.Session, repos.Email, repos.Tx, securityEventService, mailer, clk, emailPolicy), tracer)
+	resetService := service.NewTracedPasswordResetService(service.NewPasswordResetService(repos.User, repos.Session, repos.APIKey, repos.Email, repos.Tx, passwordService, securityEventService, mailer, hasher, clk, emailPolicy), tracer)
+	userService := service.NewTracedUserService(service.NewUserService(repos.User, repos.Session, repos.Tx, starter, twoFactorService, loginThrottle, passwordService, verificationService, hasher, a.Metrics, cfg.Auth.Email.RequireVerification), tracer)
+	var oidcHandler *handler.OIDCHandler
+	if cfg.Auth.OIDC.Enabled {
+		if cfg.Auth.OIDC.IssuerURL == "" || cfg.Auth.OIDC.ClientID == "" || cfg.Auth.OIDC.RedirectURL == "" {
+			return nil, errors.New("app: oidc needs an issuer url, client id and redirect url")
+		}
+		oidcService := service.NewTracedOIDCService(service.NewOIDCService(repos.User, repos.Identity, repos.OIDCState, repos.Tx, starter, twoFactorService, securityEventService, clk, service.OIDCPolicy{
+			IssuerURL:    cfg.Auth.OIDC.IssuerURL,
+			ClientID:     cfg.Auth.OIDC.ClientID,
+			ClientSecret: cfg.Auth.OIDC.ClientSecret,
+			RedirectURL:  cfg.Auth.OIDC.RedirectURL,
+			Scopes:       cfg.Auth.OIDC.Scopes,
+			StateTTL:     cfg.Auth.OIDC.StateTTL,
+		}, a.Metrics), tracer)
+		oidcHandler = handler.NewOIDCHandler(oidcService)
+	}
+	contactService := service.NewTracedContactService(service.NewContactService(repos.Contact, repos.Address, repos.Tx, a.Metrics), tracer)
+	addressService := service.NewTracedAddressService(service.NewAddressService(repos.Address, repos.Contact, repos.Tx, a.Metrics), tracer)
+	roleService := service.NewTracedRoleService(service.NewRoleService(repos.Role, repos.User, repos.Tx, clk), tracer)
+	// A secret made up at start would break every link on restart
+	exportSecret := []byte(cfg.Export.SigningSecret)
+	if len(exportSecret) < 32 {
+		return nil, errors.New("app: export signing secret must be set to at least 32 bytes")
+	}
+	if cfg.Export.TTL <= 0 || cfg.Export.PurgeInterval <= 0 {
+		return nil, errors.New("app: export ttl and purge interval must be positive")
+	}
+	exportService, err := service.NewDataExportService(repos.Export, repos.User, repos.Contact, repos.Address, repos.Session, repos.APIKey, repos.TOTP, repos.Identity, repos.Event, repos.Tx, clk, service.ExportPolicy{
+		Dir:           cfg.Export.Dir,
+		TTL:           cfg.Export.TTL,
+		SigningSecret: exportSecret,
+		BaseURL:       cfg.Export.BaseURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.exports = service.NewTracedDataExportService(exportService, tracer)
+	if cfg.Auth.Deletion.PurgeInterval <= 0 {
+		return nil, errors.New("app: deletion purge interval must be positive")
+	}
+	a.deletions = service.NewTracedAccountDeletionService(service.NewAccountDeletionService(repos.User, repos.Contact, repos.Address, repos.Session, repos.Attempt, repos.Deletion, repos.Tx, a.exports, twoFactorService, loginThrottle, securityEventService, hasher, clk, cfg.Auth.Deletion.GracePeriod), tracer)
+	adminService := service.NewTracedAdminService(service.NewAdminService(repos.User, repos.Contact, repos.Role, repos.Session, repos.Tx, passwordService, securityEventService, hasher, clk), tracer)
+
+	// Handlers and middleware
+	proxies, err := utils.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	r := router.SetupRoutes(&router.Dependencies{
+		UserHandler:          handler.NewUserHandler(userService, passwordService, a.deletions),
+		ContactHandler:       handler.NewContactHandler(contactService),
+		AddressHandler:       handler.NewAddressHandler(addressService),
+		SessionHandler:       handler.NewSessionHandler(sessionService),
+		APIKeyHandler:        handler.NewAPIKeyHandler(apiKeyService),
+		TwoFactorHandler:     handler.NewTwoFactorHandler(twoFactorService),
+		SecurityEventHandler: handler.NewSecurityEventHandler(securityEventService),
+		EmailHandler:         handler.NewEmailHandler(verificationService, resetService),
+		AdminHandler:         handler.NewAdminHandler(adminService, roleService),
+		ExportHandler:        handler.NewExportHandler(a.exports),
+		TokenHandler:         tokenHandler,
+		OIDCHandler:          oidcHandler,
+		HealthHandler:        handler.NewHealthHandler(db, migrator, cfg.Server.ReadinessTimeout, a.ShuttingDown),
+		AuthMiddleware:       middleware.NewAuthMiddleware(authenticator, apiKeyService, roleService),
+		Tracing:              middleware.TracingMiddleware(cfg.Tracing.ServiceName, tr.Provider, tr.Propagator),
+		ClientIP:             middleware.ClientIPMiddleware(proxies),
+		RequestLogger:        middleware.NewRequestLogger(log),
+		Metrics:              a.Metrics,
+		MetricsPath:          cfg.Metrics.Path,
+	})
+
+	a.handler = middleware.CORSMiddleware()(r)
+
+	return a, nil
+}
+
+// BeginShutdown makes the readiness endpoint report the instance as
+// unavailable so it is taken out of rotation before the server stops.
+func (a *App) BeginShutdown() {
+	a.shuttingDown.Store(true)
+}
+
+func (a *App) ShuttingDown() bool {
+	return a.shuttingDown.Load()
+}
+
+// RunJobs runs the background jobs of the instance until ctx is done: purging
+// the accounts whose deletion grace period ended and the expired data
+// exports.
+func (a *App) RunJobs(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		a.runJob(ctx, "purge_accounts", a.Config.Auth.Deletion.PurgeInterval, a.deletions.PurgeDue)
+	}()
+	go func() {
+		defer wg.Done()
+		a.runJob(ctx, "purge_exports", a.Config.Export.PurgeInterval, a.exports.PurgeExpired)
+	}()
+	wg.Wait()
+}
+
+// Shutdown waits for the work the instance does in the background of requests,
+// the data exports being built, until ctx is done. Call it once the server
+// stopped and before the database is closed.
+func (a *App) Shutdown(ctx context.Context) error {
+	return a.exports.Shutdown(ctx)
+}
+
+// runJob calls purge every interval until ctx is done.
+func (a *App) runJob(ctx context.Context, name string, interval time.Duration, purge func(ctx context.Context) (int, error)) {
+	ctx = logger.NewContext(ctx, logrus.NewEntry(a.Log).WithField("job", name))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := purge(ctx)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Failed to run job")
+		} else if purged > 0 {
+			logger.FromContext(ctx).WithField("purged", purged).Info("Job completed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Handler returns the root HTTP handler of the instance.
+func (a *App) Handler() http.Handler {
+	return a.handler
+}
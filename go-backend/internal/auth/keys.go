@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-backend/internal/config"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minSecretLength is the shortest HS256 secret accepted, shorter secrets can
+// be brute forced from a single token.
+const minSecretLength = 32
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	// private is nil for keys that only verify tokens.
+	private interface{}
+	public  interface{}
+}
+
+// KeySet holds the keys JWTs are signed and verified with. Every token names
+// its key in the kid header, so a key is rotated by signing with a new key
+// while the old one stays in the set until the tokens it signed expired.
+type KeySet struct {
+	keys    map[string]*key
+	signing *key
+}
+
+// NewKeySet loads the configured keys. signingKeyID names the key new tokens
+// are signed with, it needs private key material.
+func NewKeySet(signingKeyID string, configs []config.JWTKeyConfig) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*key, len(configs))}
+	for i := range configs {
+		k, err := loadKey(&configs[i])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := set.keys[k.id]; ok {
+			return nil, fmt.Errorf("auth: duplicate jwt key id %q", k.id)
+		}
+		set.keys[k.id] = k
+	}
+
+	signing, ok := set.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("auth: jwt signing key %q is not configured", signingKeyID)
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("auth: jwt signing key %q has no private key", signingKeyID)
+	}
+	set.signing = signing
+
+	return set, nil
+}
+
+func loadKey(cfg *config.JWTKeyConfig) (*key, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("auth: jwt key without id")
+	}
+	k := &key{id: cfg.ID}
+
+	var err error
+	switch cfg.Algorithm {
+	case AlgorithmHS256:
+		if len(cfg.Secret) < minSecretLength {
+			return nil, fmt.Errorf("auth: jwt key %q: secret must be at least %d bytes", cfg.ID, minSecretLength)
+		}
+		k.method = jwt.SigningMethodHS256
+		k.private = []byte(cfg.Secret)
+		k.public = k.private
+	case AlgorithmRS256:
+		k.method = jwt.SigningMethodRS256
+		err = loadPEM(cfg, k, func(pem []byte) (interface{}, error) {
+			return jwt.ParseRSAPrivateKeyFromPEM(pem)
+		}, func(pem []byte) (interface{}, error) {
+			return jwt.ParseRSAPublicKeyFromPEM(pem)
+		}, func(private interface{}) interface{} {
+			return &private.(*rsa.PrivateKey).PublicKey
+		})
+	case AlgorithmEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+		err = loadPEM(cfg, k, func(pem []byte) (interface{}, error) {
+			return jwt.ParseEdPrivateKeyFromPEM(pem)
+		}, func(pem []byte) (interface{}, error) {
+			return jwt.ParseEdPublicKeyFromPEM(pem)
+		}, func(private interface{}) interface{} {
+			return private.(ed25519.PrivateKey).Public()
+		})
+	default:
+		return nil, fmt.Errorf("auth: jwt key %q: unsupported algorithm %q", cfg.ID, cfg.Algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: jwt key %q: %w", cfg.ID, err)
+	}
+
+	return k, nil
+}
+
+// loadPEM reads the private key of an asymmetric key, or only its public key
+// for keys that verify tokens signed elsewhere.
+func loadPEM(cfg *config.JWTKeyConfig, k *key, parsePrivate, parsePublic func([]byte) (interface{}, error), publicOf func(interface{}) interface{}) error {
+	switch {
+	case cfg.PrivateKeyFile != "":
+		pem, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		if k.private, err = parsePrivate(pem); err != nil {
+			return err
+		}
+		k.public = publicOf(k.private)
+	case cfg.PublicKeyFile != "":
+		pem, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return err
+		}
+		if k.public, err = parsePublic(pem); err != nil {
+			return err
+		}
+	default:
+		return errors.New("private_key_file or public_key_file is required")
+	}
+	return nil
+}
+
+// Sign returns the signed token of claims.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	token.Header["kid"] = s.signing.id
+	return token.SignedString(s.signing.private)
+}
+
+// Parse verifies token and decodes its claims into claims. The token is only
+// accepted with the algorithm of the key it names, so a public key can never
+// be used as an HMAC secret.
+func (s *KeySet) Parse(token string, claims jwt.Claims, options ...jwt.ParserOption) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if t.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("algorithm %s does not match key %q", t.Method.Alg(), kid)
+		}
+		return k.public, nil
+	}, options...)
+	return err
+}
+
+// JWK is a public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set. HS256 keys are secret and never
+// published.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, k := range s.keys {
+		jwk := JWK{Kid: k.id, Alg: k.method.Alg(), Use: "sig"}
+		switch public := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
@@ -0,0 +1,140 @@
+// Package auth defines the authenticated identity of a request and how it is
+// carried in a context.Context.
+package auth
+
+import "context"
+
+// Method is the way a principal authenticated.
+type Method string
+
+const (
+	MethodToken  Method = "token"
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+)
+
+// The auth modes select how logins are continued: with a session token that
+// is looked up on every request, or with signed access tokens and rotating
+// refresh tokens.
+const (
+	ModeSession = "session"
+	ModeJWT     = "jwt"
+)
+
+const (
+	ScopeUserRead       = "user:read"
+	ScopeUserWrite      = "user:write"
+	ScopeContactsRead   = "contacts:read"
+	ScopeContactsWrite  = "contacts:write"
+	ScopeAddressesRead  = "addresses:read"
+	ScopeAddressesWrite = "addresses:write"
+	// ScopeUserAdmin allows managing the sessions and API keys of the user.
+	ScopeUserAdmin = "user:admin"
+	// ScopeAdmin allows the admin API, as far as the role of the user
+	// permits.
+	ScopeAdmin = "admin"
+)
+
+// AllScopes returns every scope a user can be granted.
+func AllScopes() []string {
+	return []string{ScopeUserRead, ScopeUserWrite, ScopeContactsRead, ScopeContactsWrite,
+		ScopeAddressesRead, ScopeAddressesWrite, ScopeUserAdmin, ScopeAdmin}
+}
+
+// ValidScope reports whether scope is one of AllScopes.
+func ValidScope(scope string) bool {
+	for _, s := range AllScopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions are granted to users through their role. Scopes limit what a
+// credential may do, permissions what its user may do; the admin API needs
+// both.
+const (
+	// PermissionUsersRead allows listing users and their contact counts.
+	PermissionUsersRead = "users:read"
+	// PermissionUsersWrite allows disabling and enabling users, ending their
+	// sessions and setting their passwords.
+	PermissionUsersWrite = "users:write"
+	PermissionRolesRead  = "roles:read"
+	// PermissionRolesWrite allows managing roles and assigning them, which
+	// makes it as powerful as every other permission.
+	PermissionRolesWrite = "roles:write"
+)
+
+// AllPermissions returns every permission a role can grant.
+func AllPermissions() []string {
+	return []string{PermissionUsersRead, PermissionUsersWrite, PermissionRolesRead, PermissionRolesWrite}
+}
+
+// ValidPermission reports whether permission is one of AllPermissions.
+func ValidPermission(permission string) bool {
+	for _, p := range AllPermissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal is the authenticated identity of a request.
+type Principal struct {
+	Username string
+	// SessionID identifies the session the request was authenticated with,
+	// it is empty for methods without sessions.
+	SessionID string
+	// APIKeyID identifies the API key of requests authenticated with one.
+	APIKeyID string
+	Scopes   []string
+	Method   Method
+	// Role and Permissions are those of the user at the time of the request.
+	Role        string
+	Permissions []string
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the role of the principal grants
+// permission.
+func (p *Principal) HasPermission(permission string) bool {
+	for _, s := range p.Permissions {
+		if s == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx that carries p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of ctx, if the request was authenticated.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Username returns the username of the principal of ctx, or an empty string
+// when the request was not authenticated.
+func Username(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.Username
+	}
+	return ""
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -8,19 +10,39 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Logging  LoggingConfig  `mapstructure:"logging"`
+	Metrics  MetricsConfig  `mapstructure:"metrics"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	Export   ExportConfig   `mapstructure:"export"`
 }
 
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
-	Host string `mapstructure:"host"`
+	Port              string        `mapstructure:"port"`
+	Host              string        `mapstructure:"host"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`
+	ShutdownDelay     time.Duration `mapstructure:"shutdown_delay"`
+	ReadinessTimeout  time.Duration `mapstructure:"readiness_timeout"`
+	// TrustedProxies are the addresses and CIDR ranges of reverse proxies
+	// whose Forwarded and X-Forwarded-For headers name the client.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     string `mapstructure:"port"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
-	Name     string `mapstructure:"name"`
+	Driver       string        `mapstructure:"driver"`
+	Path         string        `mapstructure:"path"`
+	Host         string        `mapstructure:"host"`
+	Port         string        `mapstructure:"port"`
+	Username     string        `mapstructure:"username"`
+	Password     string        `mapstructure:"password"`
+	Name         string        `mapstructure:"name"`
+	AutoMigrate  bool          `mapstructure:"auto_migrate"`
+	QueryTimeout time.Duration `mapstructure:"query_timeout"`
 }
 
 type LoggingConfig struct {
@@ -28,6 +50,160 @@ type LoggingConfig struct {
 	Format string `mapstructure:"format"`
 }
 
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+type AuthConfig struct {
+	Mode                 string          `mapstructure:"mode"`
+	SessionTTL           time.Duration   `mapstructure:"session_ttl"`
+	SessionIdleTimeout   time.Duration   `mapstructure:"session_idle_timeout"`
+	SessionRenewInterval time.Duration   `mapstructure:"session_renew_interval"`
+	JWT                  JWTConfig       `mapstructure:"jwt"`
+	TwoFactor            TwoFactorConfig `mapstructure:"two_factor"`
+	Lockout              LockoutConfig   `mapstructure:"lockout"`
+	Password             PasswordConfig  `mapstructure:"password"`
+	Email                EmailConfig     `mapstructure:"email"`
+	OIDC                 OIDCConfig      `mapstructure:"oidc"`
+	Deletion             DeletionConfig  `mapstructure:"deletion"`
+}
+
+// DeletionConfig controls how deleted accounts are purged.
+type DeletionConfig struct {
+	// GracePeriod is how long a user can cancel the deletion of its account.
+	GracePeriod time.Duration `mapstructure:"grace_period"`
+	// PurgeInterval is how often accounts past their grace period are
+	// looked for.
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// OIDCConfig configures login through an OpenID Connect provider. The
+// provider is found through the discovery document of IssuerURL.
+type OIDCConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	IssuerURL    string `mapstructure:"issuer_url"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL is the frontend page the provider redirects to, it posts
+	// the code and state it receives to the callback endpoint.
+	RedirectURL string        `mapstructure:"redirect_url"`
+	Scopes      []string      `mapstructure:"scopes"`
+	StateTTL    time.Duration `mapstructure:"state_ttl"`
+}
+
+// EmailConfig controls the tokens of password resets and email
+// verifications.
+type EmailConfig struct {
+	VerificationTTL time.Duration `mapstructure:"verification_ttl"`
+	ResetTTL        time.Duration `mapstructure:"reset_ttl"`
+	// ResendInterval is how long a user has to wait before another mail of
+	// the same kind is sent.
+	ResendInterval time.Duration `mapstructure:"resend_interval"`
+	// RequireVerification makes the email address mandatory at registration
+	// and limits sessions to managing the account until it is verified.
+	RequireVerification bool `mapstructure:"require_verification"`
+}
+
+// PasswordConfig is the policy new passwords have to follow.
+type PasswordConfig struct {
+	// BcryptCost is the cost of new hashes, hashes of another cost are
+	// replaced at the next login.
+	BcryptCost       int  `mapstructure:"bcrypt_cost"`
+	MinLength        int  `mapstructure:"min_length"`
+	RequireLowercase bool `mapstructure:"require_lowercase"`
+	RequireUppercase bool `mapstructure:"require_uppercase"`
+	RequireDigit     bool `mapstructure:"require_digit"`
+	RequireSymbol    bool `mapstructure:"require_symbol"`
+	DisallowUsername bool `mapstructure:"disallow_username"`
+	// HistorySize is how many of the latest passwords may not be reused.
+	HistorySize int `mapstructure:"history_size"`
+	// BreachedFile is a list of SHA-1 hashes of breached passwords, the check
+	// is skipped when empty.
+	BreachedFile string `mapstructure:"breached_file"`
+}
+
+// LockoutConfig controls how failed logins lock out a username or client
+// address.
+type LockoutConfig struct {
+	MaxFailures   int           `mapstructure:"max_failures"`
+	IPMaxFailures int           `mapstructure:"ip_max_failures"`
+	BaseDuration  time.Duration `mapstructure:"base_duration"`
+	MaxDuration   time.Duration `mapstructure:"max_duration"`
+	ResetAfter    time.Duration `mapstructure:"reset_after"`
+}
+
+type TwoFactorConfig struct {
+	Issuer       string        `mapstructure:"issuer"`
+	ChallengeTTL time.Duration `mapstructure:"challenge_ttl"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+}
+
+type JWTConfig struct {
+	Issuer     string         `mapstructure:"issuer"`
+	AccessTTL  time.Duration  `mapstructure:"access_ttl"`
+	RefreshTTL time.Duration  `mapstructure:"refresh_ttl"`
+	SigningKey string         `mapstructure:"signing_key"`
+	Keys       []JWTKeyConfig `mapstructure:"keys"`
+}
+
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// MailConfig selects how mails are delivered. The file and memory drivers
+// are meant for local use.
+type MailConfig struct {
+	Driver string `mapstructure:"driver"`
+	From   string `mapstructure:"from"`
+	// LinkBaseURL is the address of the frontend, the links in mails point to
+	// pages below it.
+	LinkBaseURL string         `mapstructure:"link_base_url"`
+	SMTP        SMTPConfig     `mapstructure:"smtp"`
+	File        FileMailConfig `mapstructure:"file"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type FileMailConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+// ExportConfig controls the archives users export their data with.
+type ExportConfig struct {
+	// Dir is where the archives are written until they expire. Downloads
+	// are served from it, so with more than one instance it has to be
+	// storage they all share.
+	Dir string `mapstructure:"dir"`
+	// TTL is how long an archive can be downloaded once it is ready.
+	TTL time.Duration `mapstructure:"ttl"`
+	// SigningSecret signs the download links, it is required and has to be
+	// the same on every instance.
+	SigningSecret string `mapstructure:"signing_secret"`
+	// BaseURL is the public address of the API, download links are relative
+	// when empty.
+	BaseURL string `mapstructure:"base_url"`
+	// PurgeInterval is how often expired archives are deleted.
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
 func LoadConfig() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -37,8 +213,68 @@ func LoadConfig() (*Config, error) {
 	// Set defaults
 	viper.SetDefault("server.port", "3001")
 	viper.SetDefault("server.host", "localhost")
+	viper.SetDefault("server.read_timeout", "15s")
+	viper.SetDefault("server.read_header_timeout", "5s")
+	viper.SetDefault("server.write_timeout", "30s")
+	viper.SetDefault("server.idle_timeout", "60s")
+	viper.SetDefault("server.max_header_bytes", 1<<20)
+	viper.SetDefault("server.shutdown_timeout", "20s")
+	viper.SetDefault("server.shutdown_delay", "0s")
+	viper.SetDefault("server.readiness_timeout", "2s")
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("database.driver", "mysql")
+	viper.SetDefault("database.path", "data/contact_management.db")
+	viper.SetDefault("database.auto_migrate", false)
+	viper.SetDefault("database.query_timeout", "5s")
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "json")
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.service_name", "contact-api")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("auth.mode", "session")
+	viper.SetDefault("auth.session_ttl", "720h")
+	viper.SetDefault("auth.session_idle_timeout", "168h")
+	viper.SetDefault("auth.session_renew_interval", "5m")
+	viper.SetDefault("auth.jwt.issuer", "contact-api")
+	viper.SetDefault("auth.jwt.access_ttl", "15m")
+	viper.SetDefault("auth.jwt.refresh_ttl", "336h")
+	viper.SetDefault("auth.two_factor.issuer", "Contact API")
+	viper.SetDefault("auth.two_factor.challenge_ttl", "5m")
+	viper.SetDefault("auth.two_factor.max_attempts", 5)
+	viper.SetDefault("auth.lockout.max_failures", 5)
+	viper.SetDefault("auth.lockout.ip_max_failures", 20)
+	viper.SetDefault("auth.lockout.base_duration", "1m")
+	viper.SetDefault("auth.lockout.max_duration", "1h")
+	viper.SetDefault("auth.lockout.reset_after", "24h")
+	viper.SetDefault("auth.password.bcrypt_cost", 12)
+	viper.SetDefault("auth.password.min_length", 10)
+	viper.SetDefault("auth.password.require_lowercase", true)
+	viper.SetDefault("auth.password.require_uppercase", true)
+	viper.SetDefault("auth.password.require_digit", true)
+	viper.SetDefault("auth.password.require_symbol", false)
+	viper.SetDefault("auth.password.disallow_username", true)
+	viper.SetDefault("auth.password.history_size", 5)
+	viper.SetDefault("auth.email.verification_ttl", "48h")
+	viper.SetDefault("auth.email.reset_ttl", "1h")
+	viper.SetDefault("auth.email.resend_interval", "1m")
+	viper.SetDefault("auth.email.require_verification", false)
+	viper.SetDefault("auth.oidc.enabled", false)
+	viper.SetDefault("auth.oidc.scopes", []string{"openid", "email", "profile"})
+	viper.SetDefault("auth.oidc.state_ttl", "10m")
+	viper.SetDefault("auth.deletion.grace_period", "720h")
+	viper.SetDefault("auth.deletion.purge_interval", "1h")
+	viper.SetDefault("mail.driver", "file")
+	viper.SetDefault("mail.from", "Contact API <no-reply@localhost>")
+	viper.SetDefault("mail.link_base_url", "http://localhost:3000")
+	viper.SetDefault("mail.smtp.port", 587)
+	viper.SetDefault("mail.file.dir", "data/mail")
+	viper.SetDefault("export.dir", "data/exports")
+	viper.SetDefault("export.ttl", "24h")
+	viper.SetDefault("export.purge_interval", "1h")
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
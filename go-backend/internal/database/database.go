@@ -1,17 +1,64 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"go-backend/internal/config"
-	"go-backend/internal/logger"
+	"os"
+	"path/filepath"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
 )
 
-var DB *sql.DB
+// DB is an open connection pool together with the dialect of its driver.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+	// QueryTimeout bounds every repository call, zero means no limit beyond
+	// the deadline of the request context.
+	QueryTimeout time.Duration
 
-func InitDatabase(cfg *config.DatabaseConfig) error {
+	tracer trace.Tracer
+}
+
+func Open(cfg *config.DatabaseConfig) (*DB, error) {
+	dialect, err := NewDialect(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var db *sql.DB
+	switch cfg.Driver {
+	case DriverSQLite:
+		db, err = openSQLite(cfg)
+	default:
+		db, err = openMySQL(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		DB:           db,
+		Dialect:      dialect,
+		QueryTimeout: cfg.QueryTimeout,
+	}, nil
+}
+
+// WithTimeout derives a context for one repository call from the request
+// context, bounded by QueryTimeout.
+func (db *DB) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.QueryTimeout)
+}
+
+func openMySQL(cfg *config.DatabaseConfig) (*sql.DB, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.Username,
 		cfg.Password,
@@ -20,22 +67,44 @@ func InitDatabase(cfg *config.DatabaseConfig) error {
 		cfg.Name,
 	)
 
-	var err error
-	DB, err = sql.Open("mysql", dsn)
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	if err = DB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	logger.Info("Database connected successfully")
-	return nil
+	return db, nil
 }
 
-func CloseDatabase() {
-	if DB != nil {
-		DB.Close()
+func openSQLite(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	inMemory := cfg.Path == ":memory:"
+	if !inMemory {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// Transactions take the write lock when they begin, which is what
+	// Dialect.ForUpdate relies on since SQLite has no row locks.
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate", cfg.Path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-}
\ No newline at end of file
+
+	// Every connection to ":memory:" gets its own empty database.
+	if inMemory {
+		db.SetMaxOpenConns(1)
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
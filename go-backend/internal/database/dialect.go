@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	// DriverMemory keeps all data in process memory and opens no SQL
+	// connection. See package repository/memory.
+	DriverMemory = "memory"
+)
+
+// Executor is the subset of *sql.DB used by repositories to run statements.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Dialect hides the SQL differences between the supported drivers so that
+// repositories can share one set of queries.
+type Dialect interface {
+	// Name returns the driver name the dialect belongs to.
+	Name() string
+	// Like returns a case-insensitive LIKE predicate for column with a single
+	// placeholder for the pattern.
+	Like(column string) string
+	// ForUpdate returns the clause appended to a SELECT to lock the selected
+	// rows until the end of the transaction.
+	ForUpdate() string
+	// Upsert returns the clause appended to an INSERT to update columns to
+	// the inserted values instead when a row with the same key exists. key
+	// lists the columns of the primary or unique key.
+	Upsert(key []string, columns ...string) string
+	// Insert runs an INSERT statement and returns the generated id.
+	Insert(ctx context.Context, exec Executor, query string, args ...interface{}) (int64, error)
+}
+
+func NewDialect(driver string) (Dialect, error) {
+	switch driver {
+	case DriverMySQL:
+		return mysqlDialect{}, nil
+	case DriverSQLite:
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return DriverMySQL
+}
+
+// Like relies on the case-insensitive utf8mb4 collation of the schema.
+func (mysqlDialect) Like(column string) string {
+	return column + " LIKE ?"
+}
+
+func (mysqlDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+// Upsert ignores key, MySQL detects conflicts on every unique key.
+func (mysqlDialect) Upsert(key []string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + " = VALUES(" + column + ")"
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+func (mysqlDialect) Insert(ctx context.Context, exec Executor, query string, args ...interface{}) (int64, error) {
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return DriverSQLite
+}
+
+// Like lowercases both sides so matching does not depend on the
+// case_sensitive_like pragma of the connection.
+func (sqliteDialect) Like(column string) string {
+	return "LOWER(" + column + ") LIKE LOWER(?)"
+}
+
+// ForUpdate is empty because transactions are opened with BEGIN IMMEDIATE,
+// which already holds the database write lock.
+func (sqliteDialect) ForUpdate() string {
+	return ""
+}
+
+func (sqliteDialect) Upsert(key []string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + " = excluded." + column
+	}
+	return " ON CONFLICT (" + strings.Join(key, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+// Insert uses RETURNING so the id comes from the same statement instead of
+// the connection-wide last_insert_rowid().
+func (sqliteDialect) Insert(ctx context.Context, exec Executor, query string, args ...interface{}) (int64, error) {
+	var id int64
+	if err := exec.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
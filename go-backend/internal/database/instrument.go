@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/logger"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer returns a copy of db whose executors run every statement in a
+// client span of tracer. The copy shares the connection pool with db.
+func (db *DB) WithTracer(tracer trace.Tracer) *DB {
+	traced := *db
+	traced.tracer = tracer
+	return &traced
+}
+
+var statementPattern = regexp.MustCompile(`(?is)^\s*(SELECT\b.*?\bFROM|INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+(\w+)`)
+
+// statementName names a statement after its operation and table, such as
+// "SELECT contacts", which keeps span names low-cardinality.
+func statementName(query string) (operation, table string) {
+	match := statementPattern.FindStringSubmatch(query)
+	if match == nil {
+		return "SQL", ""
+	}
+	return strings.ToUpper(strings.Fields(match[1])[0]), match[2]
+}
+
+// instrumentedExecutor runs every statement in a client span and logs it at
+// debug level through the request-scoped logger.
+type instrumentedExecutor struct {
+	exec   Executor
+	tracer trace.Tracer
+	system string
+}
+
+func (e instrumentedExecutor) start(ctx context.Context, query string) (context.Context, func(err error)) {
+	operation, table := statementName(query)
+	name := operation
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemKey.String(e.system),
+		semconv.DBStatement(query),
+		semconv.DBOperation(operation),
+	}
+	if table != "" {
+		name += " " + table
+		attributes = append(attributes, semconv.DBSQLTable(table))
+	}
+
+	ctx, span := e.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	start := time.Now()
+
+	return ctx, func(err error) {
+		entry := logger.FromContext(ctx).WithFields(logrus.Fields{
+			"statement":   name,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+		if err != nil {
+			entry.WithError(err).Debug("Statement failed")
+		} else {
+			entry.Debug("Statement executed")
+		}
+		endSpan(span, err)
+	}
+}
+
+func (e instrumentedExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, end := e.start(ctx, query)
+	result, err := e.exec.ExecContext(ctx, query, args...)
+	end(err)
+	return result, err
+}
+
+// QueryContext ends the span once the query returned, reading the rows is not
+// part of it.
+func (e instrumentedExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, end := e.start(ctx, query)
+	rows, err := e.exec.QueryContext(ctx, query, args...)
+	end(err)
+	return rows, err
+}
+
+func (e instrumentedExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, end := e.start(ctx, query)
+	row := e.exec.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type txKey struct{}
+
+// Executor returns the transaction started by WithinTx for ctx, or the pool
+// when ctx is not inside a transaction. Statements run through it are traced
+// and logged at debug level.
+func (db *DB) Executor(ctx context.Context) Executor {
+	var exec Executor = db.DB
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		exec = tx
+	}
+
+	tracer := db.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("")
+	}
+	return instrumentedExecutor{exec: exec, tracer: tracer, system: db.Dialect.Name()}
+}
+
+// WithinTx runs fn in a transaction. Statements issued through Executor with
+// the ctx passed to fn join the transaction, which is committed when fn
+// returns nil and rolled back otherwise. Nested calls join the outer
+// transaction.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.withinTx(ctx, nil, fn)
+}
+
+// WithinReadTx runs fn in a read-only transaction like WithinTx. It reads
+// from a consistent snapshot, REPEATABLE READ on MySQL, and on SQLite does
+// not take the write lock, so writers are not held up. Nested calls join the
+// outer transaction.
+func (db *DB) WithinReadTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.withinTx(ctx, &sql.TxOptions{ReadOnly: true}, fn)
+}
+
+func (db *DB) withinTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	if db.tracer != nil {
+		var span trace.Span
+		ctx, span = db.tracer.Start(ctx, "TRANSACTION", trace.WithSpanKind(trace.SpanKindClient))
+		defer func() { endSpan(span, err) }()
+	}
+
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
 	"go-backend/internal/models"
 	"go-backend/internal/service"
 	"net/http"
@@ -21,7 +23,7 @@ func NewAddressHandler(addressService service.AddressService) *AddressHandler {
 }
 
 func (h *AddressHandler) Create(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -44,8 +46,9 @@ func (h *AddressHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.addressService.Create(contactID, username, &req)
+	result, err := h.addressService.Create(r.Context(), contactID, username, &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to create address")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -62,7 +65,7 @@ func (h *AddressHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AddressHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -85,8 +88,9 @@ func (h *AddressHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.addressService.GetByID(addressID, contactID, username)
+	result, err := h.addressService.GetByID(r.Context(), addressID, contactID, username)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to get address")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -103,7 +107,7 @@ func (h *AddressHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AddressHandler) Update(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -136,8 +140,9 @@ func (h *AddressHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.addressService.Update(addressID, contactID, username, &req)
+	result, err := h.addressService.Update(r.Context(), addressID, contactID, username, &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to update address")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -154,7 +159,7 @@ func (h *AddressHandler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AddressHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -177,8 +182,9 @@ func (h *AddressHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.addressService.Delete(addressID, contactID, username)
+	err = h.addressService.Delete(r.Context(), addressID, contactID, username)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to delete address")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -195,7 +201,7 @@ func (h *AddressHandler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AddressHandler) GetByContactID(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -208,8 +214,9 @@ func (h *AddressHandler) GetByContactID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := h.addressService.GetByContactID(contactID, username)
+	result, err := h.addressService.GetByContactID(r.Context(), contactID, username)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to list addresses")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
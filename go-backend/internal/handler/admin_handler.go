@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"go-backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler serves the /api/admin routes. The routes check the
+// permissions, the handler only maps requests to the services.
+type AdminHandler struct {
+	adminService service.AdminService
+	roleService  service.RoleService
+}
+
+func NewAdminHandler(adminService service.AdminService, roleService service.RoleService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		roleService:  roleService,
+	}
+}
+
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	req := &models.AdminUserSearchRequest{
+		Page: 1,
+		Size: 10,
+	}
+
+	// Parse query parameters
+	if username := r.URL.Query().Get("username"); username != "" {
+		req.Username = &username
+	}
+	if name := r.URL.Query().Get("name"); name != "" {
+		req.Name = &name
+	}
+	if email := r.URL.Query().Get("email"); email != "" {
+		req.Email = &email
+	}
+	if role := r.URL.Query().Get("role"); role != "" {
+		req.Role = &role
+	}
+	if disabled := r.URL.Query().Get("disabled"); disabled != "" {
+		if d, err := strconv.ParseBool(disabled); err == nil {
+			req.Disabled = &d
+		}
+	}
+	if page := r.URL.Query().Get("page"); page != "" {
+		if p, err := strconv.Atoi(page); err == nil {
+			req.Page = p
+		}
+	}
+	if size := r.URL.Query().Get("size"); size != "" {
+		if s, err := strconv.Atoi(size); err == nil {
+			req.Size = s
+		}
+	}
+
+	result, err := h.adminService.SearchUsers(r.Context(), req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to search users")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":   result.Data,
+		"paging": result.Paging,
+	})
+}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	result, err := h.adminService.GetUser(r.Context(), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to get user")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	err := h.adminService.Disable(r.Context(), adminRequest(r), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to disable user")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	err := h.adminService.Enable(r.Context(), adminRequest(r), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to enable user")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+func (h *AdminHandler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	result, err := h.adminService.Logout(r.Context(), adminRequest(r), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to log out user")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) SetUserPassword(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	var req models.AdminPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.adminService.SetPassword(r.Context(), adminRequest(r), username, &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to set user password")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	var req models.AdminRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.adminService.SetRole(r.Context(), adminRequest(r), username, &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to set user role")
+		status := adminStatus(err)
+		if errors.Is(err, service.ErrRoleNotFound) {
+			// The role is part of the body, not of the path
+			status = http.StatusBadRequest
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	result, err := h.roleService.List(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to list roles")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req models.RoleCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.roleService.Create(r.Context(), &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to create role")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["role"]
+
+	var req models.RoleUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.roleService.Update(r.Context(), name, &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to update role")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *AdminHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["role"]
+
+	err := h.roleService.Delete(r.Context(), name)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to delete role")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(adminStatus(err))
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+// adminRequest returns who makes r.
+func adminRequest(r *http.Request) *models.AdminRequest {
+	principal, _ := auth.FromContext(r.Context())
+	return &models.AdminRequest{
+		Username:    principal.Username,
+		Permissions: principal.Permissions,
+		UserAgent:   r.UserAgent(),
+		IPAddress:   utils.ClientIP(r),
+	}
+}
+
+// adminStatus is the status of a failed admin request for the user or role
+// in its path.
+func adminStatus(err error) int {
+	if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrRoleNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, service.ErrOutranked) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.FromContext(r.Context())
+
+	var req models.APIKeyCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.apiKeyService.Create(r.Context(), principal, &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to create API key")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+
+	result, err := h.apiKeyService.List(r.Context(), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to list API keys")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+	keyID := mux.Vars(r)["keyId"]
+
+	err := h.apiKeyService.Revoke(r.Context(), username, keyID)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to revoke API key")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
 	"go-backend/internal/models"
 	"go-backend/internal/service"
 	"net/http"
@@ -21,7 +23,7 @@ func NewContactHandler(contactService service.ContactService) *ContactHandler {
 }
 
 func (h *ContactHandler) Create(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 
 	var req models.ContactCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -33,8 +35,9 @@ func (h *ContactHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.contactService.Create(username, &req)
+	result, err := h.contactService.Create(r.Context(), username, &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to create contact")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -51,7 +54,7 @@ func (h *ContactHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ContactHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -64,8 +67,9 @@ func (h *ContactHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.contactService.GetByID(contactID, username)
+	result, err := h.contactService.GetByID(r.Context(), contactID, username)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to get contact")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -82,7 +86,7 @@ func (h *ContactHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ContactHandler) Update(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -105,8 +109,9 @@ func (h *ContactHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.contactService.Update(contactID, username, &req)
+	result, err := h.contactService.Update(r.Context(), contactID, username, &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to update contact")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -123,7 +128,7 @@ func (h *ContactHandler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ContactHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 	vars := mux.Vars(r)
 	
 	contactID, err := strconv.Atoi(vars["contactId"])
@@ -136,8 +141,9 @@ func (h *ContactHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.contactService.Delete(contactID, username)
+	err = h.contactService.Delete(r.Context(), contactID, username)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to delete contact")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -154,7 +160,7 @@ func (h *ContactHandler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ContactHandler) Search(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 
 	req := &models.ContactSearchRequest{
 		Page: 1,
@@ -182,8 +188,9 @@ func (h *ContactHandler) Search(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	result, err := h.contactService.Search(username, req)
+	result, err := h.contactService.Search(r.Context(), username, req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to search contacts")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
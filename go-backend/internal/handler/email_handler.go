@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"go-backend/internal/utils"
+	"net/http"
+)
+
+// EmailHandler serves the flows that are continued through links in mails:
+// email verification and password resets.
+type EmailHandler struct {
+	verificationService service.EmailVerificationService
+	resetService        service.PasswordResetService
+}
+
+func NewEmailHandler(verificationService service.EmailVerificationService, resetService service.PasswordResetService) *EmailHandler {
+	return &EmailHandler{
+		verificationService: verificationService,
+		resetService:        resetService,
+	}
+}
+
+func (h *EmailHandler) SendVerification(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+
+	err := h.verificationService.Send(r.Context(), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to send verification mail")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+func (h *EmailHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req models.EmailVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	err := h.verificationService.Verify(r.Context(), &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to verify email address")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+func (h *EmailHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordForgotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	err := h.resetService.Request(r.Context(), &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to request password reset")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+func (h *EmailHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = utils.ClientIP(r)
+
+	err := h.resetService.Reset(r.Context(), &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to reset password")
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrInvalidEmailToken) {
+			status = http.StatusUnauthorized
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
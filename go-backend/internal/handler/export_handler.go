@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type ExportHandler struct {
+	exportService service.DataExportService
+}
+
+func NewExportHandler(exportService service.DataExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// Request starts an export of the current user's data or reports the
+// progress of the latest one. It answers 202 while the archive is built.
+func (h *ExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+
+	result, err := h.exportService.Request(r.Context(), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to request data export")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if result.Status == models.DataExportPending {
+		status = http.StatusAccepted
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+// Download serves an archive. The link is signed, so no other authentication
+// is needed.
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	exportID := mux.Vars(r)["exportId"]
+	query := r.URL.Query()
+
+	archive, err := h.exportService.Open(r.Context(), exportID, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to open data export")
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidExportLink) {
+			status = http.StatusForbidden
+		} else if errors.Is(err, service.ErrExportNotFound) {
+			status = http.StatusNotFound
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": archive.Name}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, r, archive.Name, archive.ModTime, archive)
+}
@@ -1,17 +1,132 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"go-backend/internal/database"
+	"go-backend/internal/migration"
 	"net/http"
+	"time"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	db           *database.DB
+	migrator     *migration.Migrator
+	timeout      time.Duration
+	shuttingDown func() bool
+}
+
+// NewHealthHandler returns the handler for the health endpoints. db and
+// migrator are nil when the memory driver is used.
+func NewHealthHandler(db *database.DB, migrator *migration.Migrator, timeout time.Duration, shuttingDown func() bool) *HealthHandler {
+	return &HealthHandler{
+		db:           db,
+		migrator:     migrator,
+		timeout:      timeout,
+		shuttingDown: shuttingDown,
+	}
+}
+
+type checkResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+type poolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+}
+
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+	Pool   *poolStats             `json:"pool,omitempty"`
 }
 
 func (h *HealthHandler) Ping(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("PONG"))
+}
+
+// Liveness reports that the process is running and able to serve requests. It
+// deliberately checks no dependencies.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "ok",
+	})
+}
+
+// Readiness reports whether the instance should receive traffic. It answers
+// 503 while a dependency is unhealthy or the server is shutting down.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	response := readinessResponse{
+		Status: "ok",
+		Checks: make(map[string]checkResult),
+	}
+
+	if h.shuttingDown() {
+		response.Checks["shutdown"] = checkResult{Status: "fail", Error: "server is shutting down"}
+	} else {
+		response.Checks["shutdown"] = checkResult{Status: "ok"}
+	}
+
+	if h.db == nil {
+		response.Checks["database"] = checkResult{Status: "skipped"}
+		response.Checks["migrations"] = checkResult{Status: "skipped"}
+	} else {
+		response.Checks["database"] = timed(func() error {
+			return h.db.PingContext(ctx)
+		})
+		response.Checks["migrations"] = timed(func() error {
+			return h.migrator.Check(ctx)
+		})
+
+		stats := h.db.Stats()
+		response.Pool = &poolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		}
+	}
+
+	status := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status == "fail" {
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func timed(check func() error) checkResult {
+	start := time.Now()
+	err := check()
+	result := checkResult{
+		Status:    "ok",
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
 }
\ No newline at end of file
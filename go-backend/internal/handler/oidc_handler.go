@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"go-backend/internal/utils"
+	"net/http"
+)
+
+// OIDCHandler serves the login through an OpenID Connect provider. The
+// frontend sends the user agent to the authorization URL and posts the code
+// and state the provider redirects back with to Callback.
+type OIDCHandler struct {
+	oidcService service.OIDCService
+}
+
+func NewOIDCHandler(oidcService service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	var req models.OIDCAuthorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.oidcService.Authorize(r.Context(), &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to start OIDC login")
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrOIDCUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	var req models.OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = utils.ClientIP(r)
+
+	result, err := h.oidcService.Callback(r.Context(), &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to finish OIDC login")
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCLoginFailed):
+			status = http.StatusUnauthorized
+		case errors.Is(err, service.ErrNoLinkedAccount), errors.Is(err, service.ErrUserDisabled):
+			status = http.StatusForbidden
+		case errors.Is(err, service.ErrOIDCUnavailable):
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
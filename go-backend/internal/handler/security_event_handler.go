@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"net/http"
+)
+
+type SecurityEventHandler struct {
+	eventService service.SecurityEventService
+}
+
+func NewSecurityEventHandler(eventService service.SecurityEventService) *SecurityEventHandler {
+	return &SecurityEventHandler{
+		eventService: eventService,
+	}
+}
+
+func (h *SecurityEventHandler) List(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+
+	result, err := h.eventService.List(r.Context(), username)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to list security events")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
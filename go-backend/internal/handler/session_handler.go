@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type SessionHandler struct {
+	sessionService service.SessionService
+}
+
+func NewSessionHandler(sessionService service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.FromContext(r.Context())
+
+	result, err := h.sessionService.List(r.Context(), principal.Username, principal.SessionID)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to list sessions")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+	sessionID := mux.Vars(r)["sessionId"]
+
+	err := h.sessionService.Revoke(r.Context(), username, sessionID)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to revoke session")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+func (h *SessionHandler) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.FromContext(r.Context())
+
+	result, err := h.sessionService.RevokeOthers(r.Context(), principal.Username, principal.SessionID)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to revoke other sessions")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"net/http"
+)
+
+type TokenHandler struct {
+	tokenService service.TokenService
+	keys         *auth.KeySet
+}
+
+func NewTokenHandler(tokenService service.TokenService, keys *auth.KeySet) *TokenHandler {
+	return &TokenHandler{
+		tokenService: tokenService,
+		keys:         keys,
+	}
+}
+
+func (h *TokenHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.tokenService.Refresh(r.Context(), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			status = http.StatusUnauthorized
+		} else {
+			logger.FromContext(r.Context()).WithError(err).Warn("Failed to refresh token")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+// JWKS publishes the public keys access tokens are verified with.
+func (h *TokenHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.keys.JWKS())
+}
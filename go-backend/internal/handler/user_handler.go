@@ -2,18 +2,28 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
 	"go-backend/internal/models"
 	"go-backend/internal/service"
+	"go-backend/internal/utils"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type UserHandler struct {
-	userService service.UserService
+	userService     service.UserService
+	passwordService service.PasswordService
+	deletionService service.AccountDeletionService
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, passwordService service.PasswordService, deletionService service.AccountDeletionService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:     userService,
+		passwordService: passwordService,
+		deletionService: deletionService,
 	}
 }
 
@@ -28,8 +38,9 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.userService.Register(&req)
+	result, err := h.userService.Register(r.Context(), &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to register user")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -56,10 +67,20 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.userService.Login(&req)
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = utils.ClientIP(r)
+
+	result, err := h.userService.Login(r.Context(), &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to log in")
+		status := http.StatusUnauthorized
+		if lockedOut(w, err) {
+			status = http.StatusTooManyRequests
+		} else if errors.Is(err, service.ErrUserDisabled) {
+			status = http.StatusForbidden
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
 			Errors: err.Error(),
 		})
@@ -74,10 +95,11 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 
-	result, err := h.userService.GetCurrent(username)
+	result, err := h.userService.GetCurrent(r.Context(), username)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to get current user")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -94,7 +116,7 @@ func (h *UserHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	username := auth.Username(r.Context())
 
 	var req models.UserUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -106,8 +128,9 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.userService.Update(username, &req)
+	result, err := h.userService.Update(r.Context(), username, &req)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to update user")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -123,11 +146,50 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.FromContext(r.Context())
+
+	var req models.PasswordChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = utils.ClientIP(r)
+
+	result, err := h.passwordService.Change(r.Context(), principal.Username, principal.SessionID, &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to change password")
+		status := http.StatusBadRequest
+		if lockedOut(w, err) {
+			status = http.StatusTooManyRequests
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
 func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get("X-User-Username")
+	principal, _ := auth.FromContext(r.Context())
 
-	err := h.userService.Logout(username)
+	err := h.userService.Logout(r.Context(), principal.Username, principal.SessionID)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to log out")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -141,4 +203,77 @@ func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(models.SuccessResponse{
 		Data: "OK",
 	})
-}
\ No newline at end of file
+}
+
+// Delete schedules the deletion of the current user, which can be cancelled
+// until the grace period ends.
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+
+	var req models.AccountDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: "Invalid request body",
+		})
+		return
+	}
+
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = utils.ClientIP(r)
+
+	result, err := h.deletionService.Schedule(r.Context(), username, &req)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to schedule account deletion")
+		status := http.StatusBadRequest
+		if lockedOut(w, err) {
+			status = http.StatusTooManyRequests
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: result,
+	})
+}
+
+func (h *UserHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+
+	err := h.deletionService.Cancel(r.Context(), username, r.UserAgent(), utils.ClientIP(r))
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Failed to cancel account deletion")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Data: "OK",
+	})
+}
+
+// lockedOut reports whether err is a login lockout, and then sets the
+// Retry-After header for the 429 it is answered with.
+func lockedOut(w http.ResponseWriter, err error) bool {
+	var locked *service.LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	// Round up so a client waiting Retry-After is not locked out again
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	return true
+}
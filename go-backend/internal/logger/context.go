@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type scopeKey struct{}
+
+// scope holds the request-scoped entry. It is shared by pointer so fields
+// added deeper in the handler chain, such as the username set by the auth
+// middleware, also appear on the access log line written by the outermost
+// middleware.
+type scope struct {
+	mu    sync.Mutex
+	entry *logrus.Entry
+}
+
+// NewContext returns a copy of ctx that carries entry as its logger.
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{entry: entry})
+}
+
+// FromContext returns the request-scoped logger of ctx, bound to ctx so that
+// hooks can read values such as the active span. Outside a request it falls
+// back to the logrus standard logger.
+func FromContext(ctx context.Context) *logrus.Entry {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return logrus.NewEntry(logrus.StandardLogger()).WithContext(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entry.WithContext(ctx)
+}
+
+// AddFields adds fields to the request-scoped logger of ctx for the rest of
+// the request. It does nothing when ctx carries no logger.
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entry = s.entry.WithFields(fields)
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"go-backend/internal/clock"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+)
+
+// FileMailer writes every mail as an .eml file into a directory, where it can
+// be opened with a mail client.
+type FileMailer struct {
+	dir  string
+	from *mail.Address
+	clk  clock.Clock
+	seq  atomic.Int64
+}
+
+func NewFileMailer(dir string, from *mail.Address, clk clock.Clock) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+		clk:  clk,
+	}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := m.clk.Now()
+	data, err := compose(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	// The sequence number keeps mails sent within the same second apart.
+	name := now.UTC().Format("20060102T150405") + "-" + strconv.FormatInt(m.seq.Add(1), 10) + ".eml"
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o640)
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryMailerLimit is how many of the latest mails a MemoryMailer keeps.
+const memoryMailerLimit = 100
+
+// MemoryMailer keeps the latest mails it is given, for tests and demos that
+// read them back with Messages. Only the recipient and subject are logged,
+// the body holds tokens that take over the account.
+type MemoryMailer struct {
+	log *logrus.Logger
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer(log *logrus.Logger) *MemoryMailer {
+	return &MemoryMailer{log: log}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if len(m.messages) == memoryMailerLimit {
+		m.messages = append(m.messages[:0], m.messages[1:]...)
+	}
+	m.messages = append(m.messages, *msg)
+	m.mu.Unlock()
+
+	m.log.WithContext(ctx).WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info("Mail kept in memory")
+	return nil
+}
+
+// Messages returns the mails kept so far, oldest first.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message{}, m.messages...)
+}
@@ -0,0 +1,173 @@
+// Package metrics exposes Prometheus metrics for HTTP traffic, the database
+// pool and domain events. Each Metrics has its own registry so several
+// application instances in one process do not collide.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "contact_api"
+
+// unmatchedRoute labels requests that did not match any route, so arbitrary
+// paths cannot blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+
+	logins           *prometheus.CounterVec
+	contactsCreated  prometheus.Counter
+	addressesCreated prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result.",
+		}, []string{"result"}),
+		contactsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "contacts_created_total",
+			Help:      "Contacts created.",
+		}),
+		addressesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "addresses_created_total",
+			Help:      "Addresses created.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.logins,
+		m.contactsCreated,
+		m.addressesCreated,
+	)
+
+	// Make both results visible from the start.
+	m.logins.WithLabelValues("success")
+	m.logins.WithLabelValues("failure")
+	m.logins.WithLabelValues("locked")
+
+	return m
+}
+
+// RegisterDB exposes the sql.DBStats of db as gauges and counters.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware records request counts and latency. It must be installed on the
+// mux router with Use so the matched route template is known; requests that
+// match no route are recorded by UnmatchedHandler.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serve(next, w, r, routeTemplate(r))
+	})
+}
+
+// UnmatchedHandler wraps the not found and method not allowed handlers of the
+// router.
+func (m *Metrics) UnmatchedHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serve(next, w, r, unmatchedRoute)
+	})
+}
+
+func (m *Metrics) serve(next http.Handler, w http.ResponseWriter, r *http.Request, route string) {
+	m.httpInFlight.Inc()
+	defer m.httpInFlight.Dec()
+
+	snoop := httpsnoop.CaptureMetrics(next, w, r)
+
+	m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(snoop.Code)).Inc()
+	m.httpDuration.WithLabelValues(r.Method, route).Observe(snoop.Duration.Seconds())
+}
+
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unmatchedRoute
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return template
+}
+
+// The domain recorders are safe to call on a nil *Metrics, which lets
+// services be built without metrics.
+
+func (m *Metrics) LoginSucceeded() {
+	if m != nil {
+		m.logins.WithLabelValues("success").Inc()
+	}
+}
+
+func (m *Metrics) LoginFailed() {
+	if m != nil {
+		m.logins.WithLabelValues("failure").Inc()
+	}
+}
+
+// LoginLocked counts logins rejected without checking the password because
+// the username or address is locked out.
+func (m *Metrics) LoginLocked() {
+	if m != nil {
+		m.logins.WithLabelValues("locked").Inc()
+	}
+}
+
+func (m *Metrics) ContactCreated() {
+	if m != nil {
+		m.contactsCreated.Inc()
+	}
+}
+
+func (m *Metrics) AddressCreated() {
+	if m != nil {
+		m.addressesCreated.Inc()
+	}
+}
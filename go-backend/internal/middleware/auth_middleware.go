@@ -1,26 +1,38 @@
 package middleware
 
 import (
-	"database/sql"
 	"encoding/json"
-	"go-backend/internal/database"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/logger"
 	"go-backend/internal/models"
+	"go-backend/internal/service"
+	"go-backend/internal/utils"
 	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
 )
 
 type AuthMiddleware struct {
-	db *sql.DB
+	authenticator service.Authenticator
+	apiKeys       service.APIKeyService
+	users         service.UserAuthorizer
 }
 
-func NewAuthMiddleware() *AuthMiddleware {
+func NewAuthMiddleware(authenticator service.Authenticator, apiKeys service.APIKeyService, users service.UserAuthorizer) *AuthMiddleware {
 	return &AuthMiddleware{
-		db: database.DB,
+		authenticator: authenticator,
+		apiKeys:       apiKeys,
+		users:         users,
 	}
 }
 
 func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
+		// The Bearer scheme is optional, clients of the session mode send the
+		// bare token
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
 		if token == "" {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -30,8 +42,8 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		user := m.findUserByToken(token)
-		if user == nil {
+		principal := m.authenticate(r, token)
+		if principal == nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(models.ErrorResponse{
@@ -40,23 +52,117 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// The user is looked up on every request, so disabling it or changing
+		// its role applies to tokens that were issued before
+		if err := m.users.Authorize(r.Context(), principal); err != nil {
+			status := http.StatusUnauthorized
+			msg := "Unauthorized"
+			if errors.Is(err, service.ErrUserDisabled) {
+				status = http.StatusForbidden
+				msg = err.Error()
+				logger.FromContext(r.Context()).WithField("username", principal.Username).Info("Request of disabled user rejected")
+			} else if !errors.Is(err, service.ErrInvalidSession) {
+				logger.FromContext(r.Context()).WithError(err).Error("Failed to look up user")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Errors: msg,
+			})
+			return
+		}
+
 		// Add user to context
-		r = r.WithContext(r.Context())
-		r.Header.Set("X-User-Username", user.Username)
+		r = r.WithContext(auth.NewContext(r.Context(), principal))
+		fields := logrus.Fields{"username": principal.Username}
+		if principal.SessionID != "" {
+			fields["session_id"] = principal.SessionID
+		}
+		if principal.APIKeyID != "" {
+			fields["api_key_id"] = principal.APIKeyID
+		}
+		logger.AddFields(r.Context(), fields)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
-func (m *AuthMiddleware) findUserByToken(token string) *models.User {
-	query := `SELECT username, password, name, token FROM users WHERE token = ?`
-	row := m.db.QueryRow(query, token)
+func (m *AuthMiddleware) authenticate(r *http.Request, token string) *auth.Principal {
+	ctx := r.Context()
 
-	var user models.User
-	err := row.Scan(&user.Username, &user.Password, &user.Name, &user.Token)
+	var principal *auth.Principal
+	var err error
+	if strings.HasPrefix(token, service.APIKeyPrefix) {
+		principal, err = m.apiKeys.Authenticate(ctx, token, utils.ClientIP(r))
+	}
+	// Random session tokens can start with the API key prefix as well
+	if principal == nil && (err == nil || errors.Is(err, service.ErrInvalidSession)) {
+		principal, err = m.authenticator.Authenticate(ctx, token)
+	}
 	if err != nil {
+		if !errors.Is(err, service.ErrInvalidSession) {
+			logger.FromContext(ctx).WithError(err).Error("Failed to look up token")
+		}
 		return nil
 	}
 
-	return &user
+	return principal
+}
+
+// RequireScope rejects requests whose principal was not granted scope. It has
+// to run behind RequireAuth.
+func RequireScope(scope string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || !principal.HasScope(scope) {
+			logger.FromContext(r.Context()).WithField("scope", scope).Info("Request lacks the required scope")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Errors: "Forbidden",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission rejects requests whose user's role does not grant
+// permission. It has to run behind RequireAuth.
+func RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || !principal.HasPermission(permission) {
+			logger.FromContext(r.Context()).WithField("permission", permission).Info("Request lacks the required permission")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Errors: "Forbidden",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// identityHeaders are headers that software behind this service may trust to
+// name the authenticated user. The identity lives in the request context, so
+// any client-supplied value is a spoofing attempt.
+var identityHeaders = []string{"X-User-Username"}
+
+// StripIdentityHeaders removes client-supplied identity headers from every
+// request.
+func StripIdentityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, header := range identityHeaders {
+			if r.Header.Get(header) != "" {
+				logger.FromContext(r.Context()).WithField("header", header).Warn("Removed identity header sent by client")
+				r.Header.Del(header)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
 }
\ No newline at end of file
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"go-backend/internal/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ClientIPMiddleware resolves the client address of each request through
+// proxies and puts it into the request context, where utils.ClientIP finds
+// it. Without trusted proxies the peer address is used.
+func ClientIPMiddleware(proxies utils.TrustedProxies) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := utils.NewClientIPContext(r.Context(), proxies.ClientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"go-backend/internal/logger"
+	"go-backend/internal/utils"
+	"net/http"
+	"regexp"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits the request IDs accepted from clients so they are
+// safe to log and echo back.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type RequestLogger struct {
+	log *logrus.Logger
+}
+
+func NewRequestLogger(log *logrus.Logger) *RequestLogger {
+	return &RequestLogger{
+		log: log,
+	}
+}
+
+// Middleware assigns a request ID, or keeps a valid one sent by the client,
+// puts a logger carrying it into the request context and writes one access
+// log line per request. It must be installed on the mux router with Use so
+// the matched route template is known.
+func (m *RequestLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		fields := logrus.Fields{
+			"request_id": requestID,
+			"method":     r.Method,
+			"path":       r.URL.Path,
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				fields["route"] = template
+			}
+		}
+
+		ctx := logger.NewContext(r.Context(), m.log.WithFields(fields))
+		snoop := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		entry := logger.FromContext(ctx).WithFields(logrus.Fields{
+			"status":      snoop.Code,
+			"bytes":       snoop.Written,
+			"duration_ms": float64(snoop.Duration.Microseconds()) / 1000,
+			"remote_addr": r.RemoteAddr,
+			"client_ip":   utils.ClientIP(r),
+			"user_agent":  r.UserAgent(),
+		})
+		if snoop.Code >= http.StatusInternalServerError {
+			entry.Error("Request completed")
+		} else {
+			entry.Info("Request completed")
+		}
+	})
+}
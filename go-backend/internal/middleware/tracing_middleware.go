@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a server span named after the method and route
+// template of each request, continuing the trace of an incoming traceparent
+// header. The traceparent of the span is written to the response so clients
+// can correlate their request with the trace.
+func TracingMiddleware(service string, provider trace.TracerProvider, propagator propagation.TextMapPropagator) mux.MiddlewareFunc {
+	traced := otelmux.Middleware(service,
+		otelmux.WithTracerProvider(provider),
+		otelmux.WithPropagators(propagator),
+		otelmux.WithSpanNameFormatter(func(route string, r *http.Request) string {
+			return r.Method + " " + route
+		}),
+	)
+
+	return func(next http.Handler) http.Handler {
+		return traced(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			propagator.Inject(r.Context(), propagation.HeaderCarrier(w.Header()))
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
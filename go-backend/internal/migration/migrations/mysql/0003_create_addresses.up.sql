@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `addresses` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `street` VARCHAR(255) NULL,
+    `city` VARCHAR(100) NULL,
+    `province` VARCHAR(100) NULL,
+    `country` VARCHAR(100) NOT NULL,
+    `postal_code` VARCHAR(10) NOT NULL,
+    `contact_id` INTEGER NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `addresses_contact_id_fkey` FOREIGN KEY (`contact_id`) REFERENCES `contacts`(`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
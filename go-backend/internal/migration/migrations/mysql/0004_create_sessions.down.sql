@@ -0,0 +1,10 @@
+ALTER TABLE `users` ADD COLUMN `token` VARCHAR(100) NULL;
+
+-- Keep the most recently used session of each user.
+UPDATE `users` SET `token` = (
+    SELECT `token` FROM `sessions`
+    WHERE `sessions`.`username` = `users`.`username`
+    ORDER BY `last_used_at` DESC LIMIT 1
+);
+
+DROP TABLE IF EXISTS `sessions`;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `sessions` (
+    `id` CHAR(36) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `token` VARCHAR(100) NOT NULL,
+    `device_name` VARCHAR(100) NOT NULL DEFAULT '',
+    `user_agent` VARCHAR(255) NOT NULL DEFAULT '',
+    `ip_address` VARCHAR(45) NOT NULL DEFAULT '',
+    `created_at` DATETIME NOT NULL,
+    `last_used_at` DATETIME NOT NULL,
+    `expires_at` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `sessions_token_key` (`token`),
+    KEY `sessions_username_idx` (`username`),
+    CONSTRAINT `sessions_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Keep users logged in: the single token of each user becomes a session.
+INSERT INTO `sessions` (`id`, `username`, `token`, `created_at`, `last_used_at`, `expires_at`)
+SELECT UUID(), `username`, `token`, UTC_TIMESTAMP(), UTC_TIMESTAMP(), UTC_TIMESTAMP() + INTERVAL 30 DAY
+FROM `users` WHERE `token` IS NOT NULL;
+
+ALTER TABLE `users` DROP COLUMN `token`;
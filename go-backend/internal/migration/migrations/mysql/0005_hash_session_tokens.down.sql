@@ -0,0 +1,4 @@
+-- The tokens cannot be recovered from their hashes, so every session ends.
+DELETE FROM `sessions`;
+
+ALTER TABLE `sessions` CHANGE `token_hash` `token` VARCHAR(100) NOT NULL;
@@ -0,0 +1,5 @@
+-- Tokens are stored as their SHA-256 hash from now on, existing sessions
+-- stay valid.
+UPDATE `sessions` SET `token` = SHA2(`token`, 256);
+
+ALTER TABLE `sessions` CHANGE `token` `token_hash` CHAR(64) NOT NULL;
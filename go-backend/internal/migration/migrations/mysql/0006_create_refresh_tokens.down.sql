@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `refresh_tokens`;
+
+DELETE FROM `sessions` WHERE `token_hash` IS NULL;
+
+ALTER TABLE `sessions` MODIFY `token_hash` CHAR(64) NOT NULL;
@@ -0,0 +1,15 @@
+-- Sessions started with JWT access tokens have no session token, they are
+-- continued with the refresh tokens below.
+ALTER TABLE `sessions` MODIFY `token_hash` CHAR(64) NULL;
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `token_hash` CHAR(64) NOT NULL,
+    `session_id` CHAR(36) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `created_at` DATETIME NOT NULL,
+    `expires_at` DATETIME NOT NULL,
+    `used_at` DATETIME NULL,
+    PRIMARY KEY (`token_hash`),
+    KEY `refresh_tokens_session_id_idx` (`session_id`),
+    CONSTRAINT `refresh_tokens_session_id_fkey` FOREIGN KEY (`session_id`) REFERENCES `sessions`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
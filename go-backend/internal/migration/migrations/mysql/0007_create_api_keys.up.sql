@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` CHAR(36) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `name` VARCHAR(100) NOT NULL,
+    `prefix` VARCHAR(16) NOT NULL,
+    `key_hash` CHAR(64) NOT NULL,
+    `scopes` VARCHAR(255) NOT NULL,
+    `allowed_ips` VARCHAR(1000) NOT NULL DEFAULT '',
+    `created_at` DATETIME NOT NULL,
+    `last_used_at` DATETIME NULL,
+    `expires_at` DATETIME NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `api_keys_key_hash_key` (`key_hash`),
+    KEY `api_keys_username_idx` (`username`),
+    CONSTRAINT `api_keys_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `totp_credentials` (
+    `username` VARCHAR(100) NOT NULL,
+    `secret` VARCHAR(64) NOT NULL,
+    `last_used_step` BIGINT NOT NULL DEFAULT 0,
+    `created_at` DATETIME NOT NULL,
+    `confirmed_at` DATETIME NULL,
+    PRIMARY KEY (`username`),
+    CONSTRAINT `totp_credentials_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `recovery_codes` (
+    `code_hash` CHAR(64) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `created_at` DATETIME NOT NULL,
+    PRIMARY KEY (`code_hash`),
+    KEY `recovery_codes_username_idx` (`username`),
+    CONSTRAINT `recovery_codes_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `login_challenges` (
+    `token_hash` CHAR(64) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `device_name` VARCHAR(100) NOT NULL DEFAULT '',
+    `user_agent` VARCHAR(255) NOT NULL DEFAULT '',
+    `ip_address` VARCHAR(45) NOT NULL DEFAULT '',
+    `attempts` INT NOT NULL DEFAULT 0,
+    `created_at` DATETIME NOT NULL,
+    `expires_at` DATETIME NOT NULL,
+    PRIMARY KEY (`token_hash`),
+    KEY `login_challenges_username_idx` (`username`),
+    CONSTRAINT `login_challenges_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `security_events`;
+DROP TABLE IF EXISTS `login_attempts`;
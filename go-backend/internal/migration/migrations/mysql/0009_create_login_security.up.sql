@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `login_attempts` (
+    `subject` VARCHAR(150) NOT NULL,
+    `failures` INT NOT NULL DEFAULT 0,
+    `last_failed_at` DATETIME NOT NULL,
+    `locked_until` DATETIME NULL,
+    PRIMARY KEY (`subject`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `security_events` (
+    `id` CHAR(36) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `type` VARCHAR(50) NOT NULL,
+    `ip_address` VARCHAR(45) NOT NULL DEFAULT '',
+    `user_agent` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `security_events_username_idx` (`username`, `created_at`),
+    CONSTRAINT `security_events_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `password_history` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `username` VARCHAR(100) NOT NULL,
+    `password_hash` VARCHAR(255) NOT NULL,
+    `created_at` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `password_history_username_idx` (`username`, `id`),
+    CONSTRAINT `password_history_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- The current password of every user is the first entry of its history
+INSERT INTO `password_history` (`username`, `password_hash`, `created_at`)
+SELECT `username`, `password`, UTC_TIMESTAMP() FROM `users`;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `email_tokens`;
+
+ALTER TABLE `sessions` DROP COLUMN `scopes`;
+
+ALTER TABLE `users`
+    DROP INDEX `users_email_idx`,
+    DROP COLUMN `email_verified_at`,
+    DROP COLUMN `email`;
\ No newline at end of file
@@ -0,0 +1,20 @@
+ALTER TABLE `users`
+    ADD COLUMN `email` VARCHAR(200) NULL,
+    ADD COLUMN `email_verified_at` DATETIME NULL,
+    ADD UNIQUE KEY `users_email_idx` (`email`);
+
+-- Sessions of unverified accounts are limited, existing sessions keep every
+-- scope.
+ALTER TABLE `sessions` ADD COLUMN `scopes` VARCHAR(255) NOT NULL DEFAULT 'user:read user:write contacts:read contacts:write addresses:read addresses:write user:admin';
+
+CREATE TABLE IF NOT EXISTS `email_tokens` (
+    `token_hash` CHAR(64) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `purpose` VARCHAR(20) NOT NULL,
+    `email` VARCHAR(200) NOT NULL,
+    `created_at` DATETIME NOT NULL,
+    `expires_at` DATETIME NOT NULL,
+    PRIMARY KEY (`token_hash`),
+    KEY `email_tokens_username_idx` (`username`, `purpose`),
+    CONSTRAINT `email_tokens_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `oidc_states` (
+    `state_hash` CHAR(64) NOT NULL,
+    `nonce` VARCHAR(100) NOT NULL,
+    `code_verifier` VARCHAR(128) NOT NULL,
+    `device_name` VARCHAR(100) NOT NULL DEFAULT '',
+    `created_at` DATETIME NOT NULL,
+    `expires_at` DATETIME NOT NULL,
+    PRIMARY KEY (`state_hash`),
+    KEY `oidc_states_expires_at_idx` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Subjects are only unique per issuer and compared case-sensitively.
+CREATE TABLE IF NOT EXISTS `user_identities` (
+    `issuer` VARCHAR(255) COLLATE utf8mb4_bin NOT NULL,
+    `subject` VARCHAR(255) COLLATE utf8mb4_bin NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `email` VARCHAR(200) NOT NULL,
+    `created_at` DATETIME NOT NULL,
+    PRIMARY KEY (`issuer`, `subject`),
+    KEY `user_identities_username_idx` (`username`),
+    CONSTRAINT `user_identities_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,9 @@
+UPDATE `sessions` SET `scopes` = REPLACE(`scopes`, ' admin', '');
+
+ALTER TABLE `users`
+    DROP FOREIGN KEY `users_role_fkey`,
+    DROP INDEX `users_role_idx`,
+    DROP COLUMN `disabled_at`,
+    DROP COLUMN `role`;
+
+DROP TABLE IF EXISTS `roles`;
\ No newline at end of file
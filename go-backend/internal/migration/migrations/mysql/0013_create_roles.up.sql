@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `roles` (
+    `name` VARCHAR(50) NOT NULL,
+    `description` VARCHAR(200) NOT NULL DEFAULT '',
+    `permissions` VARCHAR(1000) NOT NULL DEFAULT '',
+    `created_at` DATETIME NOT NULL,
+    `updated_at` DATETIME NOT NULL,
+    PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO `roles` (`name`, `description`, `permissions`, `created_at`, `updated_at`) VALUES
+    ('user', 'Every account', '', UTC_TIMESTAMP(), UTC_TIMESTAMP()),
+    ('admin', 'Manages users and roles', 'users:read users:write roles:read roles:write', UTC_TIMESTAMP(), UTC_TIMESTAMP());
+
+ALTER TABLE `users`
+    ADD COLUMN `role` VARCHAR(50) NOT NULL DEFAULT 'user',
+    ADD COLUMN `disabled_at` DATETIME NULL,
+    ADD KEY `users_role_idx` (`role`),
+    ADD CONSTRAINT `users_role_fkey` FOREIGN KEY (`role`) REFERENCES `roles`(`name`) ON UPDATE CASCADE;
+
+-- Existing sessions with every scope get the new admin scope as well.
+UPDATE `sessions` SET `scopes` = CONCAT(`scopes`, ' admin') WHERE `scopes` LIKE '%contacts:read%';
\ No newline at end of file
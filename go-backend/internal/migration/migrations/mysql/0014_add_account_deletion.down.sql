@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `account_deletions`;
+
+ALTER TABLE `users`
+    DROP INDEX `users_deletion_due_at_idx`,
+    DROP COLUMN `deletion_due_at`,
+    DROP COLUMN `deletion_requested_at`;
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE `users`
+    ADD COLUMN `deletion_requested_at` DATETIME NULL,
+    ADD COLUMN `deletion_due_at` DATETIME NULL,
+    ADD KEY `users_deletion_due_at_idx` (`deletion_due_at`);
+
+-- One row per purged account. It records what was removed and when, but
+-- nothing that identifies the user.
+CREATE TABLE IF NOT EXISTS `account_deletions` (
+    `id` CHAR(36) NOT NULL,
+    `requested_at` DATETIME NOT NULL,
+    `purged_at` DATETIME NOT NULL,
+    `contact_count` INT NOT NULL,
+    `address_count` INT NOT NULL,
+    `session_count` INT NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `account_deletions_purged_at_idx` (`purged_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- The archives themselves are files, a row tracks one export from the
+-- request until the archive expired.
+CREATE TABLE IF NOT EXISTS `data_exports` (
+    `id` CHAR(36) NOT NULL,
+    `username` VARCHAR(100) NOT NULL,
+    `status` VARCHAR(20) NOT NULL,
+    `size` BIGINT NOT NULL DEFAULT 0,
+    `created_at` DATETIME NOT NULL,
+    `completed_at` DATETIME NULL,
+    `expires_at` DATETIME NULL,
+    PRIMARY KEY (`id`),
+    KEY `data_exports_username_created_at_idx` (`username`, `created_at`),
+    KEY `data_exports_created_at_idx` (`created_at`),
+    CONSTRAINT `data_exports_username_fkey` FOREIGN KEY (`username`) REFERENCES `users`(`username`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS users (
+    username VARCHAR(100) NOT NULL COLLATE NOCASE PRIMARY KEY,
+    password VARCHAR(100) NOT NULL,
+    name     VARCHAR(100) NOT NULL,
+    token    VARCHAR(100) NULL
+);
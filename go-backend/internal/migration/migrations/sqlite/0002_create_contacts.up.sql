@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS contacts (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name VARCHAR(100) NOT NULL,
+    last_name  VARCHAR(100) NULL,
+    email      VARCHAR(200) NULL,
+    phone      VARCHAR(20)  NULL,
+    username   VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE RESTRICT ON UPDATE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS addresses (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    street      VARCHAR(255) NULL,
+    city        VARCHAR(100) NULL,
+    province    VARCHAR(100) NULL,
+    country     VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(10)  NOT NULL,
+    contact_id  INTEGER      NOT NULL REFERENCES contacts (id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
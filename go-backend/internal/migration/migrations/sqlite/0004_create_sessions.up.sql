@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id           CHAR(36)     NOT NULL PRIMARY KEY,
+    username     VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    token        VARCHAR(100) NOT NULL UNIQUE,
+    device_name  VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent   VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address   VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at   DATETIME     NOT NULL,
+    last_used_at DATETIME     NOT NULL,
+    expires_at   DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_username_idx ON sessions (username);
+
+-- Keep users logged in: the single token of each user becomes a session.
+INSERT INTO sessions (id, username, token, created_at, last_used_at, expires_at)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(6))),
+       username, token, datetime('now'), datetime('now'), datetime('now', '+30 days')
+FROM users WHERE token IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN token;
@@ -0,0 +1,4 @@
+-- The tokens cannot be recovered from their hashes, so every session ends.
+DELETE FROM sessions;
+
+ALTER TABLE sessions RENAME COLUMN token_hash TO token;
@@ -0,0 +1,5 @@
+-- Tokens are stored as their SHA-256 hash from now on. SQLite has no SHA-256
+-- function, so existing sessions end and their users log in again.
+DELETE FROM sessions;
+
+ALTER TABLE sessions RENAME COLUMN token TO token_hash;
@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS refresh_tokens;
+
+CREATE TABLE sessions_old (
+    id           CHAR(36)     NOT NULL PRIMARY KEY,
+    username     VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    token_hash   VARCHAR(100) NOT NULL UNIQUE,
+    device_name  VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent   VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address   VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at   DATETIME     NOT NULL,
+    last_used_at DATETIME     NOT NULL,
+    expires_at   DATETIME     NOT NULL
+);
+
+INSERT INTO sessions_old SELECT id, username, token_hash, device_name, user_agent, ip_address, created_at, last_used_at, expires_at FROM sessions WHERE token_hash IS NOT NULL;
+
+DROP TABLE sessions;
+
+ALTER TABLE sessions_old RENAME TO sessions;
+
+CREATE INDEX IF NOT EXISTS sessions_username_idx ON sessions (username);
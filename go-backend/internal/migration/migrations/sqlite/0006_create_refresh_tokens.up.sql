@@ -0,0 +1,33 @@
+-- Sessions started with JWT access tokens have no session token, they are
+-- continued with the refresh tokens below. SQLite cannot drop a NOT NULL
+-- constraint, so the sessions table is rebuilt.
+CREATE TABLE sessions_new (
+    id           CHAR(36)     NOT NULL PRIMARY KEY,
+    username     VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    token_hash   CHAR(64)     NULL UNIQUE,
+    device_name  VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent   VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address   VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at   DATETIME     NOT NULL,
+    last_used_at DATETIME     NOT NULL,
+    expires_at   DATETIME     NOT NULL
+);
+
+INSERT INTO sessions_new SELECT id, username, token_hash, device_name, user_agent, ip_address, created_at, last_used_at, expires_at FROM sessions;
+
+DROP TABLE sessions;
+
+ALTER TABLE sessions_new RENAME TO sessions;
+
+CREATE INDEX IF NOT EXISTS sessions_username_idx ON sessions (username);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash CHAR(64)     NOT NULL PRIMARY KEY,
+    session_id CHAR(36)     NOT NULL REFERENCES sessions (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    username   VARCHAR(100) NOT NULL COLLATE NOCASE,
+    created_at DATETIME     NOT NULL,
+    expires_at DATETIME     NOT NULL,
+    used_at    DATETIME     NULL
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON refresh_tokens (session_id);
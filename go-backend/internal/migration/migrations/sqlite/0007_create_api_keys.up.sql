@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           CHAR(36)      NOT NULL PRIMARY KEY,
+    username     VARCHAR(100)  NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    name         VARCHAR(100)  NOT NULL,
+    prefix       VARCHAR(16)   NOT NULL,
+    key_hash     CHAR(64)      NOT NULL UNIQUE,
+    scopes       VARCHAR(255)  NOT NULL,
+    allowed_ips  VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at   DATETIME      NOT NULL,
+    last_used_at DATETIME      NULL,
+    expires_at   DATETIME      NULL
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_username_idx ON api_keys (username);
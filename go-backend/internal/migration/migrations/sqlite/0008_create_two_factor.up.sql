@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS totp_credentials (
+    username       VARCHAR(100) NOT NULL PRIMARY KEY COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    secret         VARCHAR(64)  NOT NULL,
+    last_used_step BIGINT       NOT NULL DEFAULT 0,
+    created_at     DATETIME     NOT NULL,
+    confirmed_at   DATETIME     NULL
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    code_hash  CHAR(64)     NOT NULL PRIMARY KEY,
+    username   VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS recovery_codes_username_idx ON recovery_codes (username);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+    token_hash  CHAR(64)     NOT NULL PRIMARY KEY,
+    username    VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    device_name VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent  VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address  VARCHAR(45)  NOT NULL DEFAULT '',
+    attempts    INT          NOT NULL DEFAULT 0,
+    created_at  DATETIME     NOT NULL,
+    expires_at  DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS login_challenges_username_idx ON login_challenges (username);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    subject        VARCHAR(150) NOT NULL PRIMARY KEY,
+    failures       INT          NOT NULL DEFAULT 0,
+    last_failed_at DATETIME     NOT NULL,
+    locked_until   DATETIME     NULL
+);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id         CHAR(36)     NOT NULL PRIMARY KEY,
+    username   VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    type       VARCHAR(50)  NOT NULL,
+    ip_address VARCHAR(45)  NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS security_events_username_idx ON security_events (username, created_at);
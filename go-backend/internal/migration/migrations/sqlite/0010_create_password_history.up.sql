@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS password_history (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    username      VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS password_history_username_idx ON password_history (username, id);
+
+-- The current password of every user is the first entry of its history
+INSERT INTO password_history (username, password_hash, created_at)
+SELECT username, password, CURRENT_TIMESTAMP FROM users;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS email_tokens;
+
+ALTER TABLE sessions DROP COLUMN scopes;
+
+DROP INDEX IF EXISTS users_email_idx;
+
+ALTER TABLE users DROP COLUMN email_verified_at;
+ALTER TABLE users DROP COLUMN email;
\ No newline at end of file
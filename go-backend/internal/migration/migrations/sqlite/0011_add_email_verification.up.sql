@@ -0,0 +1,19 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(200) NULL COLLATE NOCASE;
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON users (email);
+
+-- Sessions of unverified accounts are limited, existing sessions keep every
+-- scope.
+ALTER TABLE sessions ADD COLUMN scopes VARCHAR(255) NOT NULL DEFAULT 'user:read user:write contacts:read contacts:write addresses:read addresses:write user:admin';
+
+CREATE TABLE IF NOT EXISTS email_tokens (
+    token_hash CHAR(64)     NOT NULL PRIMARY KEY,
+    username   VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    purpose    VARCHAR(20)  NOT NULL,
+    email      VARCHAR(200) NOT NULL,
+    created_at DATETIME     NOT NULL,
+    expires_at DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS email_tokens_username_idx ON email_tokens (username, purpose);
\ No newline at end of file
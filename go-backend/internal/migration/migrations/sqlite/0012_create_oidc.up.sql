@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS oidc_states (
+    state_hash    CHAR(64)     NOT NULL PRIMARY KEY,
+    nonce         VARCHAR(100) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    device_name   VARCHAR(100) NOT NULL DEFAULT '',
+    created_at    DATETIME     NOT NULL,
+    expires_at    DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS oidc_states_expires_at_idx ON oidc_states (expires_at);
+
+-- Subjects are only unique per issuer and compared case-sensitively.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer     VARCHAR(255) NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    username   VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    email      VARCHAR(200) NOT NULL,
+    created_at DATETIME     NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_username_idx ON user_identities (username);
\ No newline at end of file
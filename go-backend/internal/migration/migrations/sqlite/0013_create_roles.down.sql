@@ -0,0 +1,8 @@
+UPDATE sessions SET scopes = REPLACE(scopes, ' admin', '');
+
+DROP INDEX IF EXISTS users_role_idx;
+
+ALTER TABLE users DROP COLUMN disabled_at;
+ALTER TABLE users DROP COLUMN role;
+
+DROP TABLE IF EXISTS roles;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name        VARCHAR(50)   NOT NULL PRIMARY KEY,
+    description VARCHAR(200)  NOT NULL DEFAULT '',
+    permissions VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at  DATETIME      NOT NULL,
+    updated_at  DATETIME      NOT NULL
+);
+
+INSERT INTO roles (name, description, permissions, created_at, updated_at) VALUES
+    ('user', 'Every account', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('admin', 'Manages users and roles', 'users:read users:write roles:read roles:write', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+-- SQLite cannot add a column that references another table with a non-null
+-- default, the services make sure the role exists.
+ALTER TABLE users ADD COLUMN role VARCHAR(50) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN disabled_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS users_role_idx ON users (role);
+
+-- Existing sessions with every scope get the new admin scope as well.
+UPDATE sessions SET scopes = scopes || ' admin' WHERE scopes LIKE '%contacts:read%';
\ No newline at end of file
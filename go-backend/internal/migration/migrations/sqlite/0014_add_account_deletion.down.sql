@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS account_deletions;
+
+DROP INDEX IF EXISTS users_deletion_due_at_idx;
+
+ALTER TABLE users DROP COLUMN deletion_due_at;
+ALTER TABLE users DROP COLUMN deletion_requested_at;
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN deletion_requested_at DATETIME NULL;
+ALTER TABLE users ADD COLUMN deletion_due_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS users_deletion_due_at_idx ON users (deletion_due_at);
+
+-- One row per purged account. It records what was removed and when, but
+-- nothing that identifies the user.
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id            CHAR(36) NOT NULL PRIMARY KEY,
+    requested_at  DATETIME NOT NULL,
+    purged_at     DATETIME NOT NULL,
+    contact_count INTEGER  NOT NULL,
+    address_count INTEGER  NOT NULL,
+    session_count INTEGER  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS account_deletions_purged_at_idx ON account_deletions (purged_at);
\ No newline at end of file
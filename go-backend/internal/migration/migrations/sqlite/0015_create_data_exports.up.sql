@@ -0,0 +1,14 @@
+-- The archives themselves are files, a row tracks one export from the
+-- request until the archive expired.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id           CHAR(36)     NOT NULL PRIMARY KEY,
+    username     VARCHAR(100) NOT NULL COLLATE NOCASE REFERENCES users (username) ON DELETE CASCADE ON UPDATE CASCADE,
+    status       VARCHAR(20)  NOT NULL,
+    size         INTEGER      NOT NULL DEFAULT 0,
+    created_at   DATETIME     NOT NULL,
+    completed_at DATETIME     NULL,
+    expires_at   DATETIME     NULL
+);
+
+CREATE INDEX IF NOT EXISTS data_exports_username_created_at_idx ON data_exports (username, created_at);
+CREATE INDEX IF NOT EXISTS data_exports_created_at_idx ON data_exports (created_at);
\ No newline at end of file
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type AdminUserSearchRequest struct {
+	Username *string `json:"username,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Role     *string `json:"role,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+	Page     int     `json:"page" validate:"min=1"`
+	Size     int     `json:"size" validate:"min=1,max=100"`
+}
+
+type AdminUserResponse struct {
+	Username      string     `json:"username"`
+	Name          string     `json:"name"`
+	Email         *string    `json:"email,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"`
+	ContactCount  int        `json:"contact_count"`
+}
+
+type AdminUserSearchResponse struct {
+	Data   []AdminUserResponse `json:"data"`
+	Paging PagingResponse      `json:"paging"`
+}
+
+type AdminRoleRequest struct {
+	Role string `json:"role" validate:"required,max=50"`
+}
+
+type AdminPasswordRequest struct {
+	NewPassword string `json:"new_password" validate:"required,max=100"`
+}
+
+// AdminRequest carries who acts in an admin request, for the checks that
+// keep admins from locking themselves out and for security events.
+type AdminRequest struct {
+	Username string
+	// Permissions are those of the role of Username, they bound the users
+	// and roles the request may manage.
+	Permissions []string
+	UserAgent   string
+	IPAddress   string
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// APIKey lets scripts authenticate as a user without a password. Only the
+// hash of the key is stored, Prefix is kept to recognize it in listings.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	Username   string     `json:"username" db:"username"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips" db:"allowed_ips"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+}
+
+type APIKeyCreateRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,max=10,dive,required,max=50"`
+	// AllowedIPs are addresses or CIDR ranges, the key is accepted from
+	// anywhere when empty.
+	AllowedIPs []string   `json:"allowed_ips,omitempty" validate:"max=20,dive,required,max=50"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	// Key is only returned when the key is created.
+	Key string `json:"key,omitempty"`
+}
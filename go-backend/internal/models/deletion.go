@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// AccountDeleteRequest schedules the deletion of the current user. The
+// password, and the second factor when one is enabled, are asked for again.
+type AccountDeleteRequest struct {
+	Password string `json:"password" validate:"required,max=100"`
+	Code     string `json:"code,omitempty" validate:"omitempty,max=32"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionDueAt time.Time `json:"deletion_due_at"`
+}
+
+// AccountDeletion is the audit record of a purged account. It keeps what was
+// removed and when, but nothing that identifies the user.
+type AccountDeletion struct {
+	ID           string    `db:"id"`
+	RequestedAt  time.Time `db:"requested_at"`
+	PurgedAt     time.Time `db:"purged_at"`
+	ContactCount int       `db:"contact_count"`
+	AddressCount int       `db:"address_count"`
+	SessionCount int       `db:"session_count"`
+}
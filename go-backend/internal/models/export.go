@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// The states of a data export.
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is an archive of everything stored about a user. It is built in
+// the background, ExpiresAt is set once it is ready.
+type DataExport struct {
+	ID          string     `db:"id"`
+	Username    string     `db:"username"`
+	Status      string     `db:"status"`
+	Size        int64      `db:"size"`
+	CreatedAt   time.Time  `db:"created_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+}
+
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// DownloadURL is a signed link to the archive, it is set once the export
+	// is ready and stops working when it expires.
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// ExportProfile is the account of a user as written to profile.json.
+type ExportProfile struct {
+	Username            string     `json:"username"`
+	Name                string     `json:"name"`
+	Email               *string    `json:"email"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+	Role                string     `json:"role"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+	DeletionDueAt       *time.Time `json:"deletion_due_at,omitempty"`
+}
+
+// ExportContact is a contact with its addresses as written to contacts.json.
+type ExportContact struct {
+	ContactResponse
+	Addresses []AddressResponse `json:"addresses"`
+}
+
+// ExportIdentity is an identity provider account linked to the user.
+type ExportIdentity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
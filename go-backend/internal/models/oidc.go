@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// OIDCState is a login that was sent to the identity provider and has not
+// come back yet. Only the hash of the state is stored, the nonce and the
+// PKCE code verifier are needed to finish the login.
+type OIDCState struct {
+	StateHash    string    `db:"state_hash"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	DeviceName   string    `db:"device_name"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// UserIdentity links the account of an identity provider, named by its
+// issuer and subject, to a user. Email is the address it was linked by.
+type UserIdentity struct {
+	Issuer    string    `db:"issuer"`
+	Subject   string    `db:"subject"`
+	Username  string    `db:"username"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type OIDCAuthorizeRequest struct {
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=100"`
+}
+
+type OIDCAuthorizeResponse struct {
+	// AuthorizationURL is where the user agent is sent to log in at the
+	// identity provider.
+	AuthorizationURL string `json:"authorization_url"`
+	// State comes back with the redirect, the client checks that it matches
+	// before calling back.
+	State string `json:"state"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=100"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
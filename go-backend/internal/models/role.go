@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// The built-in roles cannot be changed or deleted. New users get RoleUser,
+// RoleAdmin always has every permission.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Role is a named set of permissions, every user has one.
+type Role struct {
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	BuiltIn     bool      `json:"built_in" db:"-"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type RoleCreateRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=200"`
+	Permissions []string `json:"permissions" validate:"max=20"`
+}
+
+type RoleUpdateRequest struct {
+	Description *string `json:"description,omitempty" validate:"omitempty,max=200"`
+	// Permissions replace the permissions of the role when set.
+	Permissions *[]string `json:"permissions,omitempty" validate:"omitempty,max=20"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+const (
+	SecurityEventLoginFailed       = "login_failed"
+	SecurityEventAccountLocked     = "account_locked"
+	SecurityEventPasswordChanged   = "password_changed"
+	SecurityEventPasswordReset     = "password_reset"
+	SecurityEventEmailVerified     = "email_verified"
+	SecurityEventIdentityLinked    = "identity_linked"
+	SecurityEventDeletionScheduled = "deletion_scheduled"
+	SecurityEventDeletionCancelled = "deletion_cancelled"
+	// The events below are caused by an admin.
+	SecurityEventAccountDisabled = "account_disabled"
+	SecurityEventAccountEnabled  = "account_enabled"
+	SecurityEventSessionsRevoked = "sessions_revoked"
+	SecurityEventRoleChanged     = "role_changed"
+)
+
+// The purposes of email tokens.
+const (
+	EmailTokenVerify = "verify_email"
+	EmailTokenReset  = "reset_password"
+)
+
+// LoginAttempt counts the failed logins of a subject, which is a username or
+// a client address.
+type LoginAttempt struct {
+	Subject      string     `db:"subject"`
+	Failures     int        `db:"failures"`
+	LastFailedAt time.Time  `db:"last_failed_at"`
+	LockedUntil  *time.Time `db:"locked_until"`
+}
+
+// SecurityEvent is an event of an account that its owner should be able to
+// review, like failed logins and lockouts.
+type SecurityEvent struct {
+	ID        string    `json:"id" db:"id"`
+	Username  string    `json:"-" db:"username"`
+	Type      string    `json:"type" db:"type"`
+	IPAddress string    `json:"ip_address" db:"ip_address"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// PasswordHistoryEntry is a password a user had, the newest entry is the
+// current password.
+type PasswordHistoryEntry struct {
+	ID           int64     `db:"id"`
+	Username     string    `db:"username"`
+	PasswordHash string    `db:"password_hash"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// EmailToken is a single-use token that was mailed to Email, the address the
+// user had when it was issued.
+type EmailToken struct {
+	TokenHash string    `db:"token_hash"`
+	Username  string    `db:"username"`
+	Purpose   string    `db:"purpose"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
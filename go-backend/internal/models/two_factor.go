@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// TOTPCredential is the TOTP secret of a user. Two-factor authentication is
+// only enabled once ConfirmedAt is set.
+type TOTPCredential struct {
+	Username string `db:"username"`
+	Secret   string `db:"secret"`
+	// LastUsedStep is the time step of the last accepted code, codes of
+	// that or an earlier step are rejected so a code works only once.
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+}
+
+type RecoveryCode struct {
+	CodeHash  string    `db:"code_hash"`
+	Username  string    `db:"username"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// LoginChallenge is a login that passed the password step and waits for the
+// second factor. It keeps the device details for the session started once
+// the code is verified.
+type LoginChallenge struct {
+	TokenHash  string    `db:"token_hash"`
+	Username   string    `db:"username"`
+	DeviceName string    `db:"device_name"`
+	UserAgent  string    `db:"user_agent"`
+	IPAddress  string    `db:"ip_address"`
+	Attempts   int       `db:"attempts"`
+	CreatedAt  time.Time `db:"created_at"`
+	ExpiresAt  time.Time `db:"expires_at"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	// QRCode is the URI as a PNG image in a data: URL.
+	QRCode string `json:"qr_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// TwoFactorDisableRequest turns two-factor authentication off. Code is a
+// TOTP code or a recovery code, the password is asked for again.
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required,max=100"`
+	Code     string `json:"code" validate:"required,max=32"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorLoginRequest completes a login, Code is a TOTP code or a recovery
+// code.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required,max=100"`
+	Code           string `json:"code" validate:"required,max=32"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
@@ -1,33 +1,93 @@
 package models
 
+import "time"
+
 type User struct {
-	Username string  `json:"username" db:"username"`
-	Password string  `json:"password,omitempty" db:"password"`
-	Name     string  `json:"name" db:"name"`
-	Token    *string `json:"token,omitempty" db:"token"`
+	Username string `json:"username" db:"username"`
+	Password string `json:"password,omitempty" db:"password"`
+	Name     string `json:"name" db:"name"`
+	// Email is nil for accounts registered before addresses were collected,
+	// EmailVerifiedAt is nil until the address is confirmed.
+	Email           *string    `json:"email,omitempty" db:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	Role            string     `json:"role" db:"role"`
+	// DisabledAt is set while an admin disabled the account.
+	DisabledAt *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	// DeletionRequestedAt and DeletionDueAt are set while the account is
+	// scheduled for deletion, it is purged once DeletionDueAt has passed.
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty" db:"deletion_requested_at"`
+	DeletionDueAt       *time.Time `json:"deletion_due_at,omitempty" db:"deletion_due_at"`
 }
 
 type UserRegisterRequest struct {
 	Username string `json:"username" validate:"required,max=100"`
 	Password string `json:"password" validate:"required,max=100"`
 	Name     string `json:"name" validate:"required,max=100"`
+	// Email is only required with auth.email.require_verification.
+	Email string `json:"email,omitempty" validate:"omitempty,email,max=200"`
 }
 
 type UserLoginRequest struct {
-	Username string `json:"username" validate:"required,max=100"`
-	Password string `json:"password" validate:"required,max=100"`
+	Username   string `json:"username" validate:"required,max=100"`
+	Password   string `json:"password" validate:"required,max=100"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=100"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 type UserUpdateRequest struct {
-	Name     *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Name *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	// Password is rejected, it is only changed with PasswordChangeRequest,
+	// which asks for the current password.
 	Password *string `json:"password,omitempty" validate:"omitempty,max=100"`
 }
 
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=100"`
+	NewPassword     string `json:"new_password" validate:"required,max=100"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
 type UserResponse struct {
-	Username string `json:"username"`
-	Name     string `json:"name"`
+	Username      string  `json:"username"`
+	Name          string  `json:"name"`
+	Email         *string `json:"email,omitempty"`
+	EmailVerified bool    `json:"email_verified"`
+	Role          string  `json:"role"`
+	// DeletionDueAt is when the account will be deleted unless the deletion
+	// is cancelled first.
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"`
+	// The fields below are only set in the jwt auth mode, where Token is a
+	// short-lived access token.
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// When the user enabled two-factor authentication the password step only
+	// returns a challenge token, which is exchanged for the tokens above
+	// together with a code.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+type EmailVerifyRequest struct {
+	Token string `json:"token" validate:"required,max=100"`
+}
+
+type PasswordForgotRequest struct {
+	Email string `json:"email" validate:"required,email,max=200"`
+}
+
+type PasswordResetRequest struct {
+	Token       string `json:"token" validate:"required,max=100"`
+	NewPassword string `json:"new_password" validate:"required,max=100"`
+	// UserAgent and IPAddress are taken from the HTTP request.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
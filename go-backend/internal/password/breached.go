@@ -0,0 +1,84 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// rangePrefixLength is how many hex characters of the SHA-1 hash are sent in
+// a range query, the same as in the Pwned Passwords API.
+const rangePrefixLength = 5
+
+// Corpus is a collection of breached passwords that is queried by the first
+// characters of the SHA-1 hash of a password. Only the prefix leaves the
+// caller, which compares the returned suffixes itself, so the corpus can be
+// an external service that never sees the password or its full hash.
+type Corpus interface {
+	// Range returns the uppercase hash suffixes of the breached passwords
+	// whose hash starts with prefix.
+	Range(prefix string) ([]string, error)
+}
+
+// Breached reports whether password is in corpus.
+func Breached(corpus Corpus, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := corpus.Range(hash[:rangePrefixLength])
+	if err != nil {
+		return false, err
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[rangePrefixLength:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fileCorpus struct {
+	ranges map[string][]string
+}
+
+// LoadCorpus reads a corpus file in the format of the Pwned Passwords
+// downloads: one SHA-1 hash in hex per line, optionally followed by a colon
+// and the number of breaches. Blank lines and lines starting with "#" are
+// skipped.
+func LoadCorpus(path string) (Corpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	corpus := &fileCorpus{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("password: %s:%d: not a SHA-1 hash", path, line)
+		}
+
+		prefix := hash[:rangePrefixLength]
+		corpus.ranges[prefix] = append(corpus.ranges[prefix], hash[rangePrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return corpus, nil
+}
+
+func (c *fileCorpus) Range(prefix string) ([]string, error) {
+	return c.ranges[strings.ToUpper(prefix)], nil
+}
@@ -0,0 +1,53 @@
+package password
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher hashes passwords with bcrypt at a configured cost.
+type Hasher struct {
+	cost int
+	// dummyHash stands in for the stored hash of a user that does not
+	// exist, it has the same cost as real hashes so checking it takes as
+	// long.
+	dummyHash []byte
+}
+
+func NewHasher(cost int) (*Hasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("password: bcrypt cost %d is not between %d and %d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), cost)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Hasher{cost: cost, dummyHash: dummyHash}, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(bytes), err
+}
+
+// Verify reports whether hash is the hash of password, whatever its cost.
+func (h *Hasher) Verify(password string, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+// VerifyDummy takes as long as Verify with a real hash, so the response time
+// of a login does not reveal whether the username exists.
+func (h *Hasher) VerifyDummy(password string) {
+	bcrypt.CompareHashAndPassword(h.dummyHash, []byte(password))
+}
+
+// NeedsRehash reports whether hash was made with another cost than the
+// configured one and should be replaced once the password is known.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
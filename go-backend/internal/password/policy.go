@@ -0,0 +1,64 @@
+// Package password hashes passwords and checks new ones against the password
+// policy and a corpus of breached passwords.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy lists the rules a new password has to follow.
+type Policy struct {
+	MinLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// DisallowUsername rejects passwords that contain the username,
+	// ignoring case.
+	DisallowUsername bool
+}
+
+// Violations returns a message for every rule password breaks, in the order
+// of the fields of Policy.
+func (p Policy) Violations(password string, username string) []string {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("Password must be at least %d characters", p.MinLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, "Password must contain a lowercase letter")
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, "Password must contain an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "Password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "Password must contain a symbol")
+	}
+
+	if p.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "Password must not contain the username")
+	}
+
+	return violations
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+)
+
+// AccountDeletionRepository stores the audit records of purged accounts.
+type AccountDeletionRepository interface {
+	Create(ctx context.Context, deletion *models.AccountDeletion) error
+}
+
+type accountDeletionRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewAccountDeletionRepository(db *database.DB) AccountDeletionRepository {
+	return &accountDeletionRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *accountDeletionRepository) Create(ctx context.Context, deletion *models.AccountDeletion) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO account_deletions (id, requested_at, purged_at, contact_count, address_count, session_count) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, deletion.ID, deletion.RequestedAt, deletion.PurgedAt,
+		deletion.ContactCount, deletion.AddressCount, deletion.SessionCount)
+	return err
+}
@@ -1,38 +1,43 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"go-backend/internal/database"
 	"go-backend/internal/models"
 )
 
 type AddressRepository interface {
-	Create(address *models.Address) (*models.Address, error)
-	FindByID(id int, contactID int) (*models.Address, error)
-	Update(address *models.Address) error
-	Delete(id int, contactID int) error
-	FindByContactID(contactID int) ([]models.Address, error)
-	CountByID(id int, contactID int) (int, error)
+	Create(ctx context.Context, address *models.Address) (*models.Address, error)
+	FindByID(ctx context.Context, id int, contactID int) (*models.Address, error)
+	Update(ctx context.Context, address *models.Address) error
+	Delete(ctx context.Context, id int, contactID int) error
+	DeleteByContactID(ctx context.Context, contactID int) error
+	// DeleteByUsername deletes the addresses of every contact of username
+	// and returns how many there were.
+	DeleteByUsername(ctx context.Context, username string) (int, error)
+	FindByContactID(ctx context.Context, contactID int) ([]models.Address, error)
+	CountByID(ctx context.Context, id int, contactID int) (int, error)
 }
 
 type addressRepository struct {
-	db *sql.DB
+	db      *database.DB
+	dialect database.Dialect
 }
 
-func NewAddressRepository() AddressRepository {
+func NewAddressRepository(db *database.DB) AddressRepository {
 	return &addressRepository{
-		db: database.DB,
+		db:      db,
+		dialect: db.Dialect,
 	}
 }
 
-func (r *addressRepository) Create(address *models.Address) (*models.Address, error) {
-	query := `INSERT INTO addresses (street, city, province, country, postal_code, contact_id) VALUES (?, ?, ?, ?, ?, ?)`
-	result, err := r.db.Exec(query, address.Street, address.City, address.Province, address.Country, address.PostalCode, address.ContactID)
-	if err != nil {
-		return nil, err
-	}
+func (r *addressRepository) Create(ctx context.Context, address *models.Address) (*models.Address, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
 
-	id, err := result.LastInsertId()
+	query := `INSERT INTO addresses (street, city, province, country, postal_code, contact_id) VALUES (?, ?, ?, ?, ?, ?)`
+	id, err := r.dialect.Insert(ctx, r.db.Executor(ctx), query, address.Street, address.City, address.Province, address.Country, address.PostalCode, address.ContactID)
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +46,12 @@ func (r *addressRepository) Create(address *models.Address) (*models.Address, er
 	return address, nil
 }
 
-func (r *addressRepository) FindByID(id int, contactID int) (*models.Address, error) {
+func (r *addressRepository) FindByID(ctx context.Context, id int, contactID int) (*models.Address, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT id, street, city, province, country, postal_code, contact_id FROM addresses WHERE id = ? AND contact_id = ?`
-	row := r.db.QueryRow(query, id, contactID)
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, id, contactID)
 
 	var address models.Address
 	err := row.Scan(&address.ID, &address.Street, &address.City, &address.Province, &address.Country, &address.PostalCode, &address.ContactID)
@@ -57,21 +65,53 @@ func (r *addressRepository) FindByID(id int, contactID int) (*models.Address, er
 	return &address, nil
 }
 
-func (r *addressRepository) Update(address *models.Address) error {
+func (r *addressRepository) Update(ctx context.Context, address *models.Address) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `UPDATE addresses SET street = ?, city = ?, province = ?, country = ?, postal_code = ? WHERE id = ? AND contact_id = ?`
-	_, err := r.db.Exec(query, address.Street, address.City, address.Province, address.Country, address.PostalCode, address.ID, address.ContactID)
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, address.Street, address.City, address.Province, address.Country, address.PostalCode, address.ID, address.ContactID)
 	return err
 }
 
-func (r *addressRepository) Delete(id int, contactID int) error {
+func (r *addressRepository) Delete(ctx context.Context, id int, contactID int) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM addresses WHERE id = ? AND contact_id = ?`
-	_, err := r.db.Exec(query, id, contactID)
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, id, contactID)
+	return err
+}
+
+func (r *addressRepository) DeleteByContactID(ctx context.Context, contactID int) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM addresses WHERE contact_id = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, contactID)
 	return err
 }
 
-func (r *addressRepository) FindByContactID(contactID int) ([]models.Address, error) {
+func (r *addressRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM addresses WHERE contact_id IN (SELECT id FROM contacts WHERE username = ?)`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+func (r *addressRepository) FindByContactID(ctx context.Context, contactID int) ([]models.Address, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT id, street, city, province, country, postal_code, contact_id FROM addresses WHERE contact_id = ?`
-	rows, err := r.db.Query(query, contactID)
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, contactID)
 	if err != nil {
 		return nil, err
 	}
@@ -90,9 +130,12 @@ func (r *addressRepository) FindByContactID(contactID int) ([]models.Address, er
 	return addresses, nil
 }
 
-func (r *addressRepository) CountByID(id int, contactID int) (int, error) {
+func (r *addressRepository) CountByID(ctx context.Context, id int, contactID int) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM addresses WHERE id = ? AND contact_id = ?`
-	row := r.db.QueryRow(query, id, contactID)
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, id, contactID)
 
 	var count int
 	err := row.Scan(&count)
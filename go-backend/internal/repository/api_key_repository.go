@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"strings"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	FindByID(ctx context.Context, id string, username string) (*models.APIKey, error)
+	FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// FindByUsername returns the keys of username, newest first.
+	FindByUsername(ctx context.Context, username string) ([]models.APIKey, error)
+	Touch(ctx context.Context, id string, lastUsedAt time.Time) error
+	Delete(ctx context.Context, id string, username string) error
+	// DeleteByUsername deletes every key of username and returns how many
+	// there were.
+	DeleteByUsername(ctx context.Context, username string) (int, error)
+}
+
+type apiKeyRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewAPIKeyRepository(db *database.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+// Scopes and allowed IPs are stored space separated.
+const apiKeyColumns = `id, username, name, prefix, key_hash, scopes, allowed_ips, created_at, last_used_at, expires_at`
+
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes, allowedIPs string
+	err := row.Scan(&key.ID, &key.Username, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &allowedIPs,
+		&key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	key.AllowedIPs = strings.Fields(allowedIPs)
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO api_keys (` + apiKeyColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, key.ID, key.Username, key.Name, key.Prefix, key.KeyHash,
+		strings.Join(key.Scopes, " "), strings.Join(key.AllowedIPs, " "), key.CreatedAt, key.LastUsedAt, key.ExpiresAt)
+	return err
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string, username string) (*models.APIKey, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ? AND username = ?`
+	key, err := scanAPIKey(r.db.Executor(ctx).QueryRowContext(ctx, query, id, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+	key, err := scanAPIKey(r.db.Executor(ctx).QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindByUsername(ctx context.Context, username string) ([]models.APIKey, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE username = ? ORDER BY created_at DESC`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, lastUsedAt, id)
+	return err
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, id string, username string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM api_keys WHERE id = ? AND username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, id, username)
+	return err
+}
+
+func (r *apiKeyRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM api_keys WHERE username = ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"go-backend/internal/database"
@@ -9,32 +10,45 @@ import (
 )
 
 type ContactRepository interface {
-	Create(contact *models.Contact) (*models.Contact, error)
-	FindByID(id int, username string) (*models.Contact, error)
-	Update(contact *models.Contact) error
-	Delete(id int, username string) error
-	Search(req *models.ContactSearchRequest, username string) ([]models.Contact, int, error)
-	CountByID(id int, username string) (int, error)
+	Create(ctx context.Context, contact *models.Contact) (*models.Contact, error)
+	FindByID(ctx context.Context, id int, username string) (*models.Contact, error)
+	Update(ctx context.Context, contact *models.Contact) error
+	Delete(ctx context.Context, id int, username string) error
+	// DeleteByUsername deletes every contact of username and returns how
+	// many there were. Their addresses have to be deleted first.
+	DeleteByUsername(ctx context.Context, username string) (int, error)
+	Search(ctx context.Context, req *models.ContactSearchRequest, username string) ([]models.Contact, int, error)
+	// FindPageByUsername returns up to limit contacts of username with an id
+	// above afterID, ordered by id, so that all of them can be read page by
+	// page without skipping or repeating any.
+	FindPageByUsername(ctx context.Context, username string, afterID int, limit int) ([]models.Contact, error)
+	CountByID(ctx context.Context, id int, username string) (int, error)
+	// CountByUsernames returns how many contacts each of usernames has,
+	// keyed by the usernames as given.
+	CountByUsernames(ctx context.Context, usernames []string) (map[string]int, error)
+	// LockByID locks the contact for the rest of the transaction in ctx and
+	// reports whether it exists.
+	LockByID(ctx context.Context, id int, username string) (bool, error)
 }
 
 type contactRepository struct {
-	db *sql.DB
+	db      *database.DB
+	dialect database.Dialect
 }
 
-func NewContactRepository() ContactRepository {
+func NewContactRepository(db *database.DB) ContactRepository {
 	return &contactRepository{
-		db: database.DB,
+		db:      db,
+		dialect: db.Dialect,
 	}
 }
 
-func (r *contactRepository) Create(contact *models.Contact) (*models.Contact, error) {
-	query := `INSERT INTO contacts (first_name, last_name, email, phone, username) VALUES (?, ?, ?, ?, ?)`
-	result, err := r.db.Exec(query, contact.FirstName, contact.LastName, contact.Email, contact.Phone, contact.Username)
-	if err != nil {
-		return nil, err
-	}
+func (r *contactRepository) Create(ctx context.Context, contact *models.Contact) (*models.Contact, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
 
-	id, err := result.LastInsertId()
+	query := `INSERT INTO contacts (first_name, last_name, email, phone, username) VALUES (?, ?, ?, ?, ?)`
+	id, err := r.dialect.Insert(ctx, r.db.Executor(ctx), query, contact.FirstName, contact.LastName, contact.Email, contact.Phone, contact.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -43,9 +57,12 @@ func (r *contactRepository) Create(contact *models.Contact) (*models.Contact, er
 	return contact, nil
 }
 
-func (r *contactRepository) FindByID(id int, username string) (*models.Contact, error) {
+func (r *contactRepository) FindByID(ctx context.Context, id int, username string) (*models.Contact, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT id, first_name, last_name, email, phone, username FROM contacts WHERE id = ? AND username = ?`
-	row := r.db.QueryRow(query, id, username)
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, id, username)
 
 	var contact models.Contact
 	err := row.Scan(&contact.ID, &contact.FirstName, &contact.LastName, &contact.Email, &contact.Phone, &contact.Username)
@@ -59,19 +76,42 @@ func (r *contactRepository) FindByID(id int, username string) (*models.Contact,
 	return &contact, nil
 }
 
-func (r *contactRepository) Update(contact *models.Contact) error {
+func (r *contactRepository) Update(ctx context.Context, contact *models.Contact) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `UPDATE contacts SET first_name = ?, last_name = ?, email = ?, phone = ? WHERE id = ? AND username = ?`
-	_, err := r.db.Exec(query, contact.FirstName, contact.LastName, contact.Email, contact.Phone, contact.ID, contact.Username)
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, contact.FirstName, contact.LastName, contact.Email, contact.Phone, contact.ID, contact.Username)
 	return err
 }
 
-func (r *contactRepository) Delete(id int, username string) error {
+func (r *contactRepository) Delete(ctx context.Context, id int, username string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM contacts WHERE id = ? AND username = ?`
-	_, err := r.db.Exec(query, id, username)
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, id, username)
 	return err
 }
 
-func (r *contactRepository) Search(req *models.ContactSearchRequest, username string) ([]models.Contact, int, error) {
+func (r *contactRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM contacts WHERE username = ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+func (r *contactRepository) Search(ctx context.Context, req *models.ContactSearchRequest, username string) ([]models.Contact, int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	// Build WHERE clause
 	var conditions []string
 	var args []interface{}
@@ -80,18 +120,18 @@ func (r *contactRepository) Search(req *models.ContactSearchRequest, username st
 	args = append(args, username)
 
 	if req.Name != nil && *req.Name != "" {
-		conditions = append(conditions, "(first_name LIKE ? OR last_name LIKE ?)")
+		conditions = append(conditions, "("+r.dialect.Like("first_name")+" OR "+r.dialect.Like("last_name")+")")
 		namePattern := "%" + *req.Name + "%"
 		args = append(args, namePattern, namePattern)
 	}
 
 	if req.Email != nil && *req.Email != "" {
-		conditions = append(conditions, "email LIKE ?")
+		conditions = append(conditions, r.dialect.Like("email"))
 		args = append(args, "%"+*req.Email+"%")
 	}
 
 	if req.Phone != nil && *req.Phone != "" {
-		conditions = append(conditions, "phone LIKE ?")
+		conditions = append(conditions, r.dialect.Like("phone"))
 		args = append(args, "%"+*req.Phone+"%")
 	}
 
@@ -100,7 +140,7 @@ func (r *contactRepository) Search(req *models.ContactSearchRequest, username st
 	// Count total items
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM contacts WHERE %s", whereClause)
 	var totalItems int
-	err := r.db.QueryRow(countQuery, args...).Scan(&totalItems)
+	err := r.db.Executor(ctx).QueryRowContext(ctx, countQuery, args...).Scan(&totalItems)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -110,7 +150,7 @@ func (r *contactRepository) Search(req *models.ContactSearchRequest, username st
 	query := fmt.Sprintf("SELECT id, first_name, last_name, email, phone, username FROM contacts WHERE %s LIMIT ? OFFSET ?", whereClause)
 	args = append(args, req.Size, offset)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -129,11 +169,97 @@ func (r *contactRepository) Search(req *models.ContactSearchRequest, username st
 	return contacts, totalItems, nil
 }
 
-func (r *contactRepository) CountByID(id int, username string) (int, error) {
+func (r *contactRepository) FindPageByUsername(ctx context.Context, username string, afterID int, limit int) ([]models.Contact, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, first_name, last_name, email, phone, username FROM contacts WHERE username = ? AND id > ? ORDER BY id LIMIT ?`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []models.Contact
+	for rows.Next() {
+		var contact models.Contact
+		err := rows.Scan(&contact.ID, &contact.FirstName, &contact.LastName, &contact.Email, &contact.Phone, &contact.Username)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, rows.Err()
+}
+
+func (r *contactRepository) CountByID(ctx context.Context, id int, username string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM contacts WHERE id = ? AND username = ?`
-	row := r.db.QueryRow(query, id, username)
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, id, username)
 
 	var count int
 	err := row.Scan(&count)
 	return count, err
+}
+
+func (r *contactRepository) LockByID(ctx context.Context, id int, username string) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id FROM contacts WHERE id = ? AND username = ?` + r.dialect.ForUpdate()
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, id, username)
+
+	var lockedID int
+	err := row.Scan(&lockedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *contactRepository) CountByUsernames(ctx context.Context, usernames []string) (map[string]int, error) {
+	counts := make(map[string]int, len(usernames))
+	if len(usernames) == 0 {
+		return counts, nil
+	}
+
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(usernames)), ", ")
+	args := make([]interface{}, len(usernames))
+	for i, username := range usernames {
+		args[i] = username
+	}
+
+	query := fmt.Sprintf("SELECT username, COUNT(*) FROM contacts WHERE username IN (%s) GROUP BY username", placeholders)
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var username string
+		var count int
+		if err := rows.Scan(&username, &count); err != nil {
+			return nil, err
+		}
+		// The username column ignores case, the rows may differ from the
+		// usernames as given
+		for _, given := range usernames {
+			if strings.EqualFold(given, username) {
+				counts[given] += count
+			}
+		}
+	}
+
+	return counts, rows.Err()
 }
\ No newline at end of file
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"time"
+)
+
+// DataExportRepository tracks the data exports of users. The archives are
+// stored as files, not in the database.
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	FindByID(ctx context.Context, id string) (*models.DataExport, error)
+	// FindLatest returns the newest export of username.
+	FindLatest(ctx context.Context, username string) (*models.DataExport, error)
+	// FindByUsername returns every export of username.
+	FindByUsername(ctx context.Context, username string) ([]models.DataExport, error)
+	// Update stores the status, size, completion and expiry of export.
+	Update(ctx context.Context, export *models.DataExport) error
+	// FailPending marks the exports created before before that are still
+	// pending as failed at now and returns how many it marked.
+	FailPending(ctx context.Context, before time.Time, now time.Time) (int, error)
+	// DeleteCreatedBefore deletes the exports created at or before before
+	// and returns how many were deleted.
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int, error)
+	// DeleteByUsername deletes every export of username and returns how many
+	// were deleted.
+	DeleteByUsername(ctx context.Context, username string) (int, error)
+}
+
+type dataExportRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewDataExportRepository(db *database.DB) DataExportRepository {
+	return &dataExportRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+const dataExportColumns = `id, username, status, size, created_at, completed_at, expires_at`
+
+func scanDataExport(row scanner) (*models.DataExport, error) {
+	var export models.DataExport
+	err := row.Scan(&export.ID, &export.Username, &export.Status, &export.Size, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO data_exports (` + dataExportColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, export.ID, export.Username, export.Status, export.Size,
+		export.CreatedAt, export.CompletedAt, export.ExpiresAt)
+	return err
+}
+
+func (r *dataExportRepository) FindByID(ctx context.Context, id string) (*models.DataExport, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE id = ?`
+	export, err := scanDataExport(r.db.Executor(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) FindLatest(ctx context.Context, username string) (*models.DataExport, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE username = ? ORDER BY created_at DESC LIMIT 1`
+	export, err := scanDataExport(r.db.Executor(ctx).QueryRowContext(ctx, query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) FindByUsername(ctx context.Context, username string) ([]models.DataExport, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE username = ? ORDER BY created_at`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []models.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *dataExportRepository) Update(ctx context.Context, export *models.DataExport) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE data_exports SET status = ?, size = ?, completed_at = ?, expires_at = ? WHERE id = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, export.Status, export.Size, export.CompletedAt,
+		export.ExpiresAt, export.ID)
+	return err
+}
+
+func (r *dataExportRepository) FailPending(ctx context.Context, before time.Time, now time.Time) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE data_exports SET status = ?, completed_at = ? WHERE status = ? AND created_at < ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, models.DataExportFailed, now, models.DataExportPending, before)
+	if err != nil {
+		return 0, err
+	}
+
+	failed, err := result.RowsAffected()
+	return int(failed), err
+}
+
+func (r *dataExportRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM data_exports WHERE created_at <= ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+func (r *dataExportRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM data_exports WHERE username = ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+)
+
+// EmailTokenRepository stores the password reset and email verification
+// tokens that were mailed to users.
+type EmailTokenRepository interface {
+	Create(ctx context.Context, token *models.EmailToken) error
+	// FindByHash returns the token with tokenHash. Inside a transaction the
+	// row stays locked until it ends.
+	FindByHash(ctx context.Context, tokenHash string) (*models.EmailToken, error)
+	// FindLatest returns the most recently issued token of username for
+	// purpose.
+	FindLatest(ctx context.Context, username string, purpose string) (*models.EmailToken, error)
+	// DeleteByUsername deletes every token of username for purpose.
+	DeleteByUsername(ctx context.Context, username string, purpose string) error
+}
+
+type emailTokenRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewEmailTokenRepository(db *database.DB) EmailTokenRepository {
+	return &emailTokenRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+const emailTokenColumns = `token_hash, username, purpose, email, created_at, expires_at`
+
+func scanEmailToken(row scanner) (*models.EmailToken, error) {
+	var token models.EmailToken
+	err := row.Scan(&token.TokenHash, &token.Username, &token.Purpose, &token.Email, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *emailTokenRepository) Create(ctx context.Context, token *models.EmailToken) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO email_tokens (` + emailTokenColumns + `) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, token.TokenHash, token.Username, token.Purpose, token.Email,
+		token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+func (r *emailTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.EmailToken, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + emailTokenColumns + ` FROM email_tokens WHERE token_hash = ?` + r.dialect.ForUpdate()
+	token, err := scanEmailToken(r.db.Executor(ctx).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *emailTokenRepository) FindLatest(ctx context.Context, username string, purpose string) (*models.EmailToken, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + emailTokenColumns + ` FROM email_tokens WHERE username = ? AND purpose = ? ORDER BY created_at DESC LIMIT 1`
+	token, err := scanEmailToken(r.db.Executor(ctx).QueryRowContext(ctx, query, username, purpose))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *emailTokenRepository) DeleteByUsername(ctx context.Context, username string, purpose string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM email_tokens WHERE username = ? AND purpose = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, username, purpose)
+	return err
+}
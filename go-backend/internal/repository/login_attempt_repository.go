@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"time"
+)
+
+// LoginAttemptRepository stores the failed login counters of usernames and
+// client addresses.
+type LoginAttemptRepository interface {
+	// Find returns the counter of subject. Inside a transaction the row stays
+	// locked until it ends.
+	Find(ctx context.Context, subject string) (*models.LoginAttempt, error)
+	// Ensure creates the counter of subject without failures unless it
+	// exists, so that Find has a row to lock even for the first attempt.
+	Ensure(ctx context.Context, subject string, now time.Time) error
+	Update(ctx context.Context, attempt *models.LoginAttempt) error
+	Delete(ctx context.Context, subject string) error
+}
+
+type loginAttemptRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewLoginAttemptRepository(db *database.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *loginAttemptRepository) Find(ctx context.Context, subject string) (*models.LoginAttempt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT subject, failures, last_failed_at, locked_until FROM login_attempts WHERE subject = ?` + r.dialect.ForUpdate()
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, subject)
+
+	var attempt models.LoginAttempt
+	err := row.Scan(&attempt.Subject, &attempt.Failures, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) Ensure(ctx context.Context, subject string, now time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	// Parallel first attempts both insert, the upsert turns the second one
+	// into an update that leaves the row as it is
+	query := `INSERT INTO login_attempts (subject, failures, last_failed_at) VALUES (?, 0, ?)` +
+		r.dialect.Upsert([]string{"subject"}, "subject")
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, subject, now)
+	return err
+}
+
+func (r *loginAttemptRepository) Update(ctx context.Context, attempt *models.LoginAttempt) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE login_attempts SET failures = ?, last_failed_at = ?, locked_until = ? WHERE subject = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, attempt.Failures, attempt.LastFailedAt, attempt.LockedUntil, attempt.Subject)
+	return err
+}
+
+func (r *loginAttemptRepository) Delete(ctx context.Context, subject string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM login_attempts WHERE subject = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, subject)
+	return err
+}
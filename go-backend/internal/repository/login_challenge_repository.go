@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"time"
+)
+
+type LoginChallengeRepository interface {
+	Create(ctx context.Context, challenge *models.LoginChallenge) error
+	// FindByHash returns the challenge with tokenHash. Inside a transaction
+	// the row stays locked until it ends.
+	FindByHash(ctx context.Context, tokenHash string) (*models.LoginChallenge, error)
+	// AddAttempt counts a wrong code against the challenge.
+	AddAttempt(ctx context.Context, tokenHash string) error
+	Delete(ctx context.Context, tokenHash string) error
+	// DeleteExpired deletes the challenges of username that expired at or
+	// before now.
+	DeleteExpired(ctx context.Context, username string, now time.Time) error
+}
+
+type loginChallengeRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewLoginChallengeRepository(db *database.DB) LoginChallengeRepository {
+	return &loginChallengeRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *loginChallengeRepository) Create(ctx context.Context, challenge *models.LoginChallenge) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO login_challenges (token_hash, username, device_name, user_agent, ip_address, attempts, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, challenge.TokenHash, challenge.Username, challenge.DeviceName,
+		challenge.UserAgent, challenge.IPAddress, challenge.Attempts, challenge.CreatedAt, challenge.ExpiresAt)
+	return err
+}
+
+func (r *loginChallengeRepository) FindByHash(ctx context.Context, tokenHash string) (*models.LoginChallenge, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT token_hash, username, device_name, user_agent, ip_address, attempts, created_at, expires_at FROM login_challenges WHERE token_hash = ?` + r.dialect.ForUpdate()
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, tokenHash)
+
+	var challenge models.LoginChallenge
+	err := row.Scan(&challenge.TokenHash, &challenge.Username, &challenge.DeviceName, &challenge.UserAgent, &challenge.IPAddress,
+		&challenge.Attempts, &challenge.CreatedAt, &challenge.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+func (r *loginChallengeRepository) AddAttempt(ctx context.Context, tokenHash string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE login_challenges SET attempts = attempts + 1 WHERE token_hash = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, tokenHash)
+	return err
+}
+
+func (r *loginChallengeRepository) Delete(ctx context.Context, tokenHash string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM login_challenges WHERE token_hash = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, tokenHash)
+	return err
+}
+
+func (r *loginChallengeRepository) DeleteExpired(ctx context.Context, username string, now time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM login_challenges WHERE username = ? AND expires_at <= ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, username, now)
+	return err
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+)
+
+type accountDeletionRepository struct {
+	store *Store
+}
+
+func NewAccountDeletionRepository(store *Store) repository.AccountDeletionRepository {
+	return &accountDeletionRepository{
+		store: store,
+	}
+}
+
+func (r *accountDeletionRepository) Create(ctx context.Context, deletion *models.AccountDeletion) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.accountDeletions[deletion.ID]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.accountDeletions[deletion.ID] = *deletion
+	return nil
+}
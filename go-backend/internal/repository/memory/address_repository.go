@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+)
+
+type addressRepository struct {
+	store *Store
+}
+
+func NewAddressRepository(store *Store) repository.AddressRepository {
+	return &addressRepository{
+		store: store,
+	}
+}
+
+func (r *addressRepository) Create(ctx context.Context, address *models.Address) (*models.Address, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.contacts[address.ContactID]; !ok {
+		return nil, errForeignKey
+	}
+
+	address.ID = r.store.nextAddressID
+	r.store.nextAddressID++
+	r.store.addresses[address.ID] = copyAddress(*address)
+
+	return address, nil
+}
+
+func (r *addressRepository) FindByID(ctx context.Context, id int, contactID int) (*models.Address, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	address, ok := r.store.findAddress(id, contactID)
+	if !ok {
+		return nil, nil
+	}
+
+	address = copyAddress(address)
+	return &address, nil
+}
+
+func (r *addressRepository) Update(ctx context.Context, address *models.Address) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	existing, ok := r.store.findAddress(address.ID, address.ContactID)
+	if !ok {
+		return nil
+	}
+
+	existing.Street = copyString(address.Street)
+	existing.City = copyString(address.City)
+	existing.Province = copyString(address.Province)
+	existing.Country = address.Country
+	existing.PostalCode = address.PostalCode
+	r.store.addresses[existing.ID] = existing
+
+	return nil
+}
+
+func (r *addressRepository) Delete(ctx context.Context, id int, contactID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.findAddress(id, contactID); ok {
+		delete(r.store.addresses, id)
+	}
+	return nil
+}
+
+func (r *addressRepository) DeleteByContactID(ctx context.Context, contactID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for id, address := range r.store.addresses {
+		if address.ContactID == contactID {
+			delete(r.store.addresses, id)
+		}
+	}
+	return nil
+}
+
+func (r *addressRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	deleted := 0
+	for id, address := range r.store.addresses {
+		contact, ok := r.store.contacts[address.ContactID]
+		if ok && usernameKey(contact.Username) == usernameKey(username) {
+			delete(r.store.addresses, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *addressRepository) FindByContactID(ctx context.Context, contactID int) ([]models.Address, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var addresses []models.Address
+	for _, address := range r.store.addresses {
+		if address.ContactID == contactID {
+			addresses = append(addresses, copyAddress(address))
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].ID < addresses[j].ID
+	})
+
+	return addresses, nil
+}
+
+func (r *addressRepository) CountByID(ctx context.Context, id int, contactID int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	if _, ok := r.store.findAddress(id, contactID); ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// findAddress returns the address with id if it belongs to contactID. The
+// caller must hold the store lock.
+func (s *Store) findAddress(id int, contactID int) (models.Address, bool) {
+	address, ok := s.addresses[id]
+	if !ok || address.ContactID != contactID {
+		return models.Address{}, false
+	}
+	return address, true
+}
+
+func copyAddress(address models.Address) models.Address {
+	address.Street = copyString(address.Street)
+	address.City = copyString(address.City)
+	address.Province = copyString(address.Province)
+	return address
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type apiKeyRepository struct {
+	store *Store
+}
+
+func NewAPIKeyRepository(store *Store) repository.APIKeyRepository {
+	return &apiKeyRepository{
+		store: store,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(key.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.apiKeys[key.ID]; ok {
+		return errDuplicateKey
+	}
+	for _, existing := range r.store.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return errDuplicateKey
+		}
+	}
+
+	r.store.apiKeys[key.ID] = copyAPIKey(*key)
+	return nil
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string, username string) (*models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	key, ok := r.store.apiKeys[id]
+	if !ok || usernameKey(key.Username) != usernameKey(username) {
+		return nil, nil
+	}
+
+	key = copyAPIKey(key)
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	for _, key := range r.store.apiKeys {
+		if key.KeyHash == keyHash {
+			key = copyAPIKey(key)
+			return &key, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *apiKeyRepository) FindByUsername(ctx context.Context, username string) ([]models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var keys []models.APIKey
+	for _, key := range r.store.apiKeys {
+		if usernameKey(key.Username) == usernameKey(username) {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key, ok := r.store.apiKeys[id]
+	if !ok {
+		return nil
+	}
+
+	key.LastUsedAt = &lastUsedAt
+	r.store.apiKeys[id] = key
+	return nil
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, id string, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if key, ok := r.store.apiKeys[id]; ok && usernameKey(key.Username) == usernameKey(username) {
+		delete(r.store.apiKeys, id)
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	deleted := 0
+	for id, key := range r.store.apiKeys {
+		if usernameKey(key.Username) == usernameKey(username) {
+			delete(r.store.apiKeys, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func copyAPIKey(key models.APIKey) models.APIKey {
+	key.Scopes = append([]string{}, key.Scopes...)
+	key.AllowedIPs = append([]string{}, key.AllowedIPs...)
+	key.LastUsedAt = copyTime(key.LastUsedAt)
+	key.ExpiresAt = copyTime(key.ExpiresAt)
+	return key
+}
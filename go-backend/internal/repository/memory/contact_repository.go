@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+)
+
+type contactRepository struct {
+	store *Store
+}
+
+func NewContactRepository(store *Store) repository.ContactRepository {
+	return &contactRepository{
+		store: store,
+	}
+}
+
+func (r *contactRepository) Create(ctx context.Context, contact *models.Contact) (*models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(contact.Username)]; !ok {
+		return nil, errForeignKey
+	}
+
+	contact.ID = r.store.nextContactID
+	r.store.nextContactID++
+	r.store.contacts[contact.ID] = copyContact(*contact)
+
+	return contact, nil
+}
+
+func (r *contactRepository) FindByID(ctx context.Context, id int, username string) (*models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	contact, ok := r.store.findContact(id, username)
+	if !ok {
+		return nil, nil
+	}
+
+	contact = copyContact(contact)
+	return &contact, nil
+}
+
+func (r *contactRepository) Update(ctx context.Context, contact *models.Contact) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	existing, ok := r.store.findContact(contact.ID, contact.Username)
+	if !ok {
+		return nil
+	}
+
+	existing.FirstName = contact.FirstName
+	existing.LastName = copyString(contact.LastName)
+	existing.Email = copyString(contact.Email)
+	existing.Phone = copyString(contact.Phone)
+	r.store.contacts[existing.ID] = existing
+
+	return nil
+}
+
+func (r *contactRepository) Delete(ctx context.Context, id int, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.findContact(id, username); !ok {
+		return nil
+	}
+
+	for _, address := range r.store.addresses {
+		if address.ContactID == id {
+			return errForeignKey
+		}
+	}
+
+	delete(r.store.contacts, id)
+	return nil
+}
+
+func (r *contactRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	ids := make(map[int]bool)
+	for id, contact := range r.store.contacts {
+		if usernameKey(contact.Username) == usernameKey(username) {
+			ids[id] = true
+		}
+	}
+	for _, address := range r.store.addresses {
+		if ids[address.ContactID] {
+			return 0, errForeignKey
+		}
+	}
+
+	for id := range ids {
+		delete(r.store.contacts, id)
+	}
+	return len(ids), nil
+}
+
+func (r *contactRepository) Search(ctx context.Context, req *models.ContactSearchRequest, username string) ([]models.Contact, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var matches []models.Contact
+	for _, contact := range r.store.contacts {
+		if usernameKey(contact.Username) != usernameKey(username) {
+			continue
+		}
+
+		if req.Name != nil && *req.Name != "" {
+			namePattern := "%" + *req.Name + "%"
+			if !like(&contact.FirstName, namePattern) && !like(contact.LastName, namePattern) {
+				continue
+			}
+		}
+
+		if req.Email != nil && *req.Email != "" && !like(contact.Email, "%"+*req.Email+"%") {
+			continue
+		}
+
+		if req.Phone != nil && *req.Phone != "" && !like(contact.Phone, "%"+*req.Phone+"%") {
+			continue
+		}
+
+		matches = append(matches, contact)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+
+	totalItems := len(matches)
+
+	offset := (req.Page - 1) * req.Size
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > totalItems {
+		offset = totalItems
+	}
+	end := offset + req.Size
+	if end > totalItems {
+		end = totalItems
+	}
+
+	var contacts []models.Contact
+	for _, contact := range matches[offset:end] {
+		contacts = append(contacts, copyContact(contact))
+	}
+
+	return contacts, totalItems, nil
+}
+
+func (r *contactRepository) FindPageByUsername(ctx context.Context, username string, afterID int, limit int) ([]models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var matches []models.Contact
+	for _, contact := range r.store.contacts {
+		if usernameKey(contact.Username) == usernameKey(username) && contact.ID > afterID {
+			matches = append(matches, contact)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	var contacts []models.Contact
+	for _, contact := range matches {
+		contacts = append(contacts, copyContact(contact))
+	}
+
+	return contacts, nil
+}
+
+func (r *contactRepository) CountByID(ctx context.Context, id int, username string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	if _, ok := r.store.findContact(id, username); ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (r *contactRepository) CountByUsernames(ctx context.Context, usernames []string) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	perKey := make(map[string]int)
+	for _, contact := range r.store.contacts {
+		perKey[usernameKey(contact.Username)]++
+	}
+
+	counts := make(map[string]int, len(usernames))
+	for _, username := range usernames {
+		counts[username] = perKey[usernameKey(username)]
+	}
+	return counts, nil
+}
+
+// findContact returns the contact with id if it belongs to username. The
+// caller must hold the store lock.
+func (s *Store) findContact(id int, username string) (models.Contact, bool) {
+	contact, ok := s.contacts[id]
+	if !ok || usernameKey(contact.Username) != usernameKey(username) {
+		return models.Contact{}, false
+	}
+	return contact, true
+}
+
+func copyContact(contact models.Contact) models.Contact {
+	contact.LastName = copyString(contact.LastName)
+	contact.Email = copyString(contact.Email)
+	contact.Phone = copyString(contact.Phone)
+	return contact
+}
+
+
+func (r *contactRepository) LockByID(ctx context.Context, id int, username string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	// Inside a transaction the whole store is locked already.
+	defer r.store.rlock(ctx)()
+
+	_, ok := r.store.findContact(id, username)
+	return ok, nil
+}
\ No newline at end of file
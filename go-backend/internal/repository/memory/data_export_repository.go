@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type dataExportRepository struct {
+	store *Store
+}
+
+func NewDataExportRepository(store *Store) repository.DataExportRepository {
+	return &dataExportRepository{
+		store: store,
+	}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(export.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.dataExports[export.ID]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.dataExports[export.ID] = copyDataExport(*export)
+	return nil
+}
+
+func (r *dataExportRepository) FindByID(ctx context.Context, id string) (*models.DataExport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	export, ok := r.store.dataExports[id]
+	if !ok {
+		return nil, nil
+	}
+
+	export = copyDataExport(export)
+	return &export, nil
+}
+
+func (r *dataExportRepository) FindLatest(ctx context.Context, username string) (*models.DataExport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var latest *models.DataExport
+	for _, export := range r.store.dataExports {
+		if usernameKey(export.Username) != usernameKey(username) {
+			continue
+		}
+		if latest == nil || export.CreatedAt.After(latest.CreatedAt) {
+			export = copyDataExport(export)
+			latest = &export
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *dataExportRepository) FindByUsername(ctx context.Context, username string) ([]models.DataExport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var exports []models.DataExport
+	for _, export := range r.store.dataExports {
+		if usernameKey(export.Username) == usernameKey(username) {
+			exports = append(exports, copyDataExport(export))
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].CreatedAt.Before(exports[j].CreatedAt)
+	})
+
+	return exports, nil
+}
+
+func (r *dataExportRepository) Update(ctx context.Context, export *models.DataExport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	stored, ok := r.store.dataExports[export.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = export.Status
+	stored.Size = export.Size
+	stored.CompletedAt = copyTime(export.CompletedAt)
+	stored.ExpiresAt = copyTime(export.ExpiresAt)
+	r.store.dataExports[export.ID] = stored
+	return nil
+}
+
+func (r *dataExportRepository) FailPending(ctx context.Context, before time.Time, now time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	failed := 0
+	for id, export := range r.store.dataExports {
+		if export.Status != models.DataExportPending || !export.CreatedAt.Before(before) {
+			continue
+		}
+		export.Status = models.DataExportFailed
+		export.CompletedAt = copyTime(&now)
+		r.store.dataExports[id] = export
+		failed++
+	}
+
+	return failed, nil
+}
+
+func (r *dataExportRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	deleted := 0
+	for id, export := range r.store.dataExports {
+		if !export.CreatedAt.After(before) {
+			delete(r.store.dataExports, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *dataExportRepository) DeleteByUsername(ctx context.Context, username string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	deleted := 0
+	for id, export := range r.store.dataExports {
+		if usernameKey(export.Username) == usernameKey(username) {
+			delete(r.store.dataExports, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func copyDataExport(export models.DataExport) models.DataExport {
+	export.CompletedAt = copyTime(export.CompletedAt)
+	export.ExpiresAt = copyTime(export.ExpiresAt)
+	return export
+}
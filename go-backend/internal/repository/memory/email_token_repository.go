@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+)
+
+type emailTokenRepository struct {
+	store *Store
+}
+
+func NewEmailTokenRepository(store *Store) repository.EmailTokenRepository {
+	return &emailTokenRepository{
+		store: store,
+	}
+}
+
+func (r *emailTokenRepository) Create(ctx context.Context, token *models.EmailToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(token.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.emailTokens[token.TokenHash]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.emailTokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *emailTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.EmailToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	token, ok := r.store.emailTokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+
+	return &token, nil
+}
+
+func (r *emailTokenRepository) FindLatest(ctx context.Context, username string, purpose string) (*models.EmailToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var latest *models.EmailToken
+	for _, token := range r.store.emailTokens {
+		if usernameKey(token.Username) != usernameKey(username) || token.Purpose != purpose {
+			continue
+		}
+		if latest == nil || token.CreatedAt.After(latest.CreatedAt) {
+			token := token
+			latest = &token
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *emailTokenRepository) DeleteByUsername(ctx context.Context, username string, purpose string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for hash, token := range r.store.emailTokens {
+		if usernameKey(token.Username) == usernameKey(username) && token.Purpose == purpose {
+			delete(r.store.emailTokens, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"time"
+)
+
+type loginAttemptRepository struct {
+	store *Store
+}
+
+func NewLoginAttemptRepository(store *Store) repository.LoginAttemptRepository {
+	return &loginAttemptRepository{
+		store: store,
+	}
+}
+
+func (r *loginAttemptRepository) Find(ctx context.Context, subject string) (*models.LoginAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	attempt, ok := r.store.loginAttempts[subject]
+	if !ok {
+		return nil, nil
+	}
+
+	attempt.LockedUntil = copyTime(attempt.LockedUntil)
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) Ensure(ctx context.Context, subject string, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.loginAttempts[subject]; !ok {
+		r.store.loginAttempts[subject] = models.LoginAttempt{Subject: subject, LastFailedAt: now}
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) Update(ctx context.Context, attempt *models.LoginAttempt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.loginAttempts[attempt.Subject]; !ok {
+		return nil
+	}
+
+	r.store.loginAttempts[attempt.Subject] = copyLoginAttempt(*attempt)
+	return nil
+}
+
+func (r *loginAttemptRepository) Delete(ctx context.Context, subject string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	delete(r.store.loginAttempts, subject)
+	return nil
+}
+
+func copyLoginAttempt(attempt models.LoginAttempt) models.LoginAttempt {
+	attempt.LockedUntil = copyTime(attempt.LockedUntil)
+	return attempt
+}
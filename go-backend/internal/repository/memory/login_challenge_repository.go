@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"time"
+)
+
+type loginChallengeRepository struct {
+	store *Store
+}
+
+func NewLoginChallengeRepository(store *Store) repository.LoginChallengeRepository {
+	return &loginChallengeRepository{
+		store: store,
+	}
+}
+
+func (r *loginChallengeRepository) Create(ctx context.Context, challenge *models.LoginChallenge) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(challenge.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.loginChallenges[challenge.TokenHash]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.loginChallenges[challenge.TokenHash] = *challenge
+	return nil
+}
+
+func (r *loginChallengeRepository) FindByHash(ctx context.Context, tokenHash string) (*models.LoginChallenge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	challenge, ok := r.store.loginChallenges[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+
+	return &challenge, nil
+}
+
+func (r *loginChallengeRepository) AddAttempt(ctx context.Context, tokenHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	challenge, ok := r.store.loginChallenges[tokenHash]
+	if !ok {
+		return nil
+	}
+
+	challenge.Attempts++
+	r.store.loginChallenges[tokenHash] = challenge
+	return nil
+}
+
+func (r *loginChallengeRepository) Delete(ctx context.Context, tokenHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	delete(r.store.loginChallenges, tokenHash)
+	return nil
+}
+
+func (r *loginChallengeRepository) DeleteExpired(ctx context.Context, username string, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for hash, challenge := range r.store.loginChallenges {
+		if usernameKey(challenge.Username) == usernameKey(username) && !challenge.ExpiresAt.After(now) {
+			delete(r.store.loginChallenges, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"time"
+)
+
+type oidcStateRepository struct {
+	store *Store
+}
+
+func NewOIDCStateRepository(store *Store) repository.OIDCStateRepository {
+	return &oidcStateRepository{
+		store: store,
+	}
+}
+
+func (r *oidcStateRepository) Create(ctx context.Context, state *models.OIDCState) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.oidcStates[state.StateHash]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.oidcStates[state.StateHash] = *state
+	return nil
+}
+
+func (r *oidcStateRepository) FindByHash(ctx context.Context, stateHash string) (*models.OIDCState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	state, ok := r.store.oidcStates[stateHash]
+	if !ok {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (r *oidcStateRepository) Delete(ctx context.Context, stateHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	delete(r.store.oidcStates, stateHash)
+	return nil
+}
+
+func (r *oidcStateRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for hash, state := range r.store.oidcStates {
+		if !state.ExpiresAt.After(now) {
+			delete(r.store.oidcStates, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+)
+
+type passwordHistoryRepository struct {
+	store *Store
+}
+
+func NewPasswordHistoryRepository(store *Store) repository.PasswordHistoryRepository {
+	return &passwordHistoryRepository{
+		store: store,
+	}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, entry *models.PasswordHistoryEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(entry.Username)]; !ok {
+		return errForeignKey
+	}
+
+	entry.ID = r.store.nextPasswordHistoryID
+	r.store.nextPasswordHistoryID++
+	r.store.passwordHistory[entry.ID] = *entry
+
+	return nil
+}
+
+func (r *passwordHistoryRepository) FindRecent(ctx context.Context, username string, limit int) ([]models.PasswordHistoryEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	entries := r.byUsername(username)
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+func (r *passwordHistoryRepository) Prune(ctx context.Context, username string, keep int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	entries := r.byUsername(username)
+	for i := keep; i < len(entries); i++ {
+		delete(r.store.passwordHistory, entries[i].ID)
+	}
+
+	return nil
+}
+
+// byUsername returns the entries of username, newest first. The caller holds
+// the lock.
+func (r *passwordHistoryRepository) byUsername(username string) []models.PasswordHistoryEntry {
+	var entries []models.PasswordHistoryEntry
+	for _, entry := range r.store.passwordHistory {
+		if usernameKey(entry.Username) == usernameKey(username) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+
+	return entries
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"time"
+)
+
+type refreshTokenRepository struct {
+	store *Store
+}
+
+func NewRefreshTokenRepository(store *Store) repository.RefreshTokenRepository {
+	return &refreshTokenRepository{
+		store: store,
+	}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.sessions[token.SessionID]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.refreshTokens[token.TokenHash]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.refreshTokens[token.TokenHash] = copyRefreshToken(*token)
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	token, ok := r.store.refreshTokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+
+	token = copyRefreshToken(token)
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	token, ok := r.store.refreshTokens[tokenHash]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+
+	token.UsedAt = &usedAt
+	r.store.refreshTokens[tokenHash] = token
+	return true, nil
+}
+
+func copyRefreshToken(token models.RefreshToken) models.RefreshToken {
+	token.UsedAt = copyTime(token.UsedAt)
+	return token
+}
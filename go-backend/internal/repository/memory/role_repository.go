@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+)
+
+type roleRepository struct {
+	store *Store
+}
+
+func NewRoleRepository(store *Store) repository.RoleRepository {
+	return &roleRepository{
+		store: store,
+	}
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.roles[role.Name]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.roles[role.Name] = copyRole(*role)
+	return nil
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	role, ok := r.store.roles[name]
+	if !ok {
+		return nil, nil
+	}
+
+	role = copyRole(role)
+	return &role, nil
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]models.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var roles []models.Role
+	for _, role := range r.store.roles {
+		roles = append(roles, copyRole(role))
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+
+	return roles, nil
+}
+
+func (r *roleRepository) Update(ctx context.Context, role *models.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	existing, ok := r.store.roles[role.Name]
+	if !ok {
+		return nil
+	}
+
+	existing.Description = role.Description
+	existing.Permissions = append([]string(nil), role.Permissions...)
+	existing.UpdatedAt = role.UpdatedAt
+	r.store.roles[role.Name] = existing
+	return nil
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for _, user := range r.store.users {
+		if user.Role == name {
+			return errForeignKey
+		}
+	}
+
+	delete(r.store.roles, name)
+	return nil
+}
+
+func copyRole(role models.Role) models.Role {
+	role.Permissions = append([]string(nil), role.Permissions...)
+	return role
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+)
+
+type securityEventRepository struct {
+	store *Store
+}
+
+func NewSecurityEventRepository(store *Store) repository.SecurityEventRepository {
+	return &securityEventRepository{
+		store: store,
+	}
+}
+
+func (r *securityEventRepository) Create(ctx context.Context, event *models.SecurityEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(event.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.securityEvents[event.ID]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.securityEvents[event.ID] = *event
+	return nil
+}
+
+func (r *securityEventRepository) FindByUsername(ctx context.Context, username string, limit int) ([]models.SecurityEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var events []models.SecurityEvent
+	for _, event := range r.store.securityEvents {
+		if usernameKey(event.Username) == usernameKey(username) {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
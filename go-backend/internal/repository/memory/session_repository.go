@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type sessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) repository.SessionRepository {
+	return &sessionRepository{
+		store: store,
+	}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(session.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.sessions[session.ID]; ok {
+		return errDuplicateKey
+	}
+	if session.TokenHash != nil {
+		for _, existing := range r.store.sessions {
+			if existing.TokenHash != nil && *existing.TokenHash == *session.TokenHash {
+				return errDuplicateKey
+			}
+		}
+	}
+
+	r.store.sessions[session.ID] = copySession(*session)
+	return nil
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id string, username string) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	session, ok := r.store.sessions[id]
+	if !ok || usernameKey(session.Username) != usernameKey(username) {
+		return nil, nil
+	}
+
+	session = copySession(session)
+	return &session, nil
+}
+
+func (r *sessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	for _, session := range r.store.sessions {
+		if session.TokenHash != nil && *session.TokenHash == tokenHash {
+			session = copySession(session)
+			return &session, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *sessionRepository) FindByUsername(ctx context.Context, username string, now time.Time, idleSince time.Time) ([]models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var sessions []models.Session
+	for _, session := range r.store.sessions {
+		if usernameKey(session.Username) == usernameKey(username) && session.ExpiresAt.After(now) && session.LastUsedAt.After(idleSince) {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	session.LastUsedAt = lastUsedAt
+	r.store.sessions[id] = session
+	return nil
+}
+
+func (r *sessionRepository) UpdateScopes(ctx context.Context, username string, scopes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for id, session := range r.store.sessions {
+		if usernameKey(session.Username) == usernameKey(username) {
+			session.Scopes = append([]string{}, scopes...)
+			r.store.sessions[id] = session
+		}
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, id string, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if session, ok := r.store.sessions[id]; ok && usernameKey(session.Username) == usernameKey(username) {
+		r.store.deleteSession(id)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) DeleteOthers(ctx context.Context, username string, keepID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	deleted := 0
+	for id, session := range r.store.sessions {
+		if usernameKey(session.Username) == usernameKey(username) && id != keepID {
+			r.store.deleteSession(id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *sessionRepository) DeleteExpired(ctx context.Context, username string, now time.Time, idleSince time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for id, session := range r.store.sessions {
+		expired := !session.ExpiresAt.After(now) || !session.LastUsedAt.After(idleSince)
+		if usernameKey(session.Username) == usernameKey(username) && expired {
+			r.store.deleteSession(id)
+		}
+	}
+
+	return nil
+}
+
+func copySession(session models.Session) models.Session {
+	session.TokenHash = copyString(session.TokenHash)
+	session.Scopes = append([]string{}, session.Scopes...)
+	return session
+}
@@ -0,0 +1,435 @@
+// Package memory provides in-memory implementations of the repository
+// interfaces. They follow the same ownership, pagination and search rules as
+// the SQL repositories, which makes them suitable for tests and demo servers
+// that should run without a database.
+package memory
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errDuplicateKey = errors.New("duplicate entry for primary key")
+	errForeignKey   = errors.New("foreign key constraint failed")
+)
+
+// Store holds the rows of every table. Repositories created from the same
+// Store see each other's data, like tables of one database.
+type Store struct {
+	mu sync.RWMutex
+
+	users     map[string]models.User
+	contacts  map[int]models.Contact
+	addresses map[int]models.Address
+	sessions  map[string]models.Session
+	// refreshTokens are keyed by token hash.
+	refreshTokens map[string]models.RefreshToken
+	apiKeys       map[string]models.APIKey
+	// totpCredentials are keyed by usernameKey, recoveryCodes by code hash
+	// and loginChallenges by token hash.
+	totpCredentials map[string]models.TOTPCredential
+	recoveryCodes   map[string]models.RecoveryCode
+	loginChallenges map[string]models.LoginChallenge
+	// loginAttempts are keyed by subject, securityEvents by id.
+	loginAttempts  map[string]models.LoginAttempt
+	securityEvents map[string]models.SecurityEvent
+	// passwordHistory is keyed by id.
+	passwordHistory map[int64]models.PasswordHistoryEntry
+	// emailTokens are keyed by token hash, oidcStates by state hash.
+	emailTokens    map[string]models.EmailToken
+	oidcStates     map[string]models.OIDCState
+	userIdentities map[identityKey]models.UserIdentity
+	// roles are keyed by name, accountDeletions and dataExports by id.
+	roles            map[string]models.Role
+	accountDeletions map[string]models.AccountDeletion
+	dataExports      map[string]models.DataExport
+
+	nextContactID         int
+	nextAddressID         int
+	nextPasswordHistoryID int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:                 make(map[string]models.User),
+		contacts:              make(map[int]models.Contact),
+		addresses:             make(map[int]models.Address),
+		sessions:              make(map[string]models.Session),
+		refreshTokens:         make(map[string]models.RefreshToken),
+		apiKeys:               make(map[string]models.APIKey),
+		totpCredentials:       make(map[string]models.TOTPCredential),
+		recoveryCodes:         make(map[string]models.RecoveryCode),
+		loginChallenges:       make(map[string]models.LoginChallenge),
+		loginAttempts:         make(map[string]models.LoginAttempt),
+		securityEvents:        make(map[string]models.SecurityEvent),
+		passwordHistory:       make(map[int64]models.PasswordHistoryEntry),
+		emailTokens:           make(map[string]models.EmailToken),
+		oidcStates:            make(map[string]models.OIDCState),
+		userIdentities:        make(map[identityKey]models.UserIdentity),
+		roles:                 builtInRoles(),
+		accountDeletions:      make(map[string]models.AccountDeletion),
+		dataExports:           make(map[string]models.DataExport),
+		nextContactID:         1,
+		nextAddressID:         1,
+		nextPasswordHistoryID: 1,
+	}
+}
+
+// NewRepositories returns repositories backed by a new, empty Store.
+func NewRepositories() *repository.Repositories {
+	store := NewStore()
+	return &repository.Repositories{
+		User:      NewUserRepository(store),
+		Contact:   NewContactRepository(store),
+		Address:   NewAddressRepository(store),
+		Session:   NewSessionRepository(store),
+		Refresh:   NewRefreshTokenRepository(store),
+		APIKey:    NewAPIKeyRepository(store),
+		TOTP:      NewTOTPRepository(store),
+		Challenge: NewLoginChallengeRepository(store),
+		Attempt:   NewLoginAttemptRepository(store),
+		Event:     NewSecurityEventRepository(store),
+		Passwords: NewPasswordHistoryRepository(store),
+		Email:     NewEmailTokenRepository(store),
+		OIDCState: NewOIDCStateRepository(store),
+		Identity:  NewUserIdentityRepository(store),
+		Role:      NewRoleRepository(store),
+		Deletion:  NewAccountDeletionRepository(store),
+		Export:    NewDataExportRepository(store),
+		Tx:        store,
+	}
+}
+
+// builtInRoles returns the roles a new store starts with, like the rows the
+// migration inserts.
+func builtInRoles() map[string]models.Role {
+	now := time.Now().UTC()
+	return map[string]models.Role{
+		models.RoleUser: {
+			Name:        models.RoleUser,
+			Description: "Every account",
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		models.RoleAdmin: {
+			Name:        models.RoleAdmin,
+			Description: "Manages users and roles",
+			Permissions: auth.AllPermissions(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+	}
+}
+
+// identityKey is the primary key of user identities. Subjects are only
+// unique per issuer and compared case-sensitively.
+type identityKey struct {
+	issuer  string
+	subject string
+}
+
+type txKey struct{}
+
+// WithinTx runs fn while holding the store lock exclusively, so transactions
+// are serializable. Repository calls with the ctx passed to fn skip locking,
+// and all changes are reverted when fn returns an error or panics.
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			s.restore(snapshot)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
+// WithinReadTx runs fn holding the read lock, so it sees no writes made in
+// the meantime. fn must not write.
+func (s *Store) WithinReadTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(context.WithValue(ctx, txKey{}, s))
+}
+
+func (s *Store) inTx(ctx context.Context) bool {
+	store, _ := ctx.Value(txKey{}).(*Store)
+	return store == s
+}
+
+// lock takes the write lock unless ctx belongs to a transaction of s, which
+// holds it already. The returned function releases it.
+func (s *Store) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// rlock is the read counterpart of lock.
+func (s *Store) rlock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// snapshot copies the table maps. Rows are stored by value and never
+// modified in place, so a shallow copy of each map is enough.
+func (s *Store) snapshot() *Store {
+	snapshot := &Store{
+		users:                 make(map[string]models.User, len(s.users)),
+		contacts:              make(map[int]models.Contact, len(s.contacts)),
+		addresses:             make(map[int]models.Address, len(s.addresses)),
+		sessions:              make(map[string]models.Session, len(s.sessions)),
+		refreshTokens:         make(map[string]models.RefreshToken, len(s.refreshTokens)),
+		apiKeys:               make(map[string]models.APIKey, len(s.apiKeys)),
+		totpCredentials:       make(map[string]models.TOTPCredential, len(s.totpCredentials)),
+		recoveryCodes:         make(map[string]models.RecoveryCode, len(s.recoveryCodes)),
+		loginChallenges:       make(map[string]models.LoginChallenge, len(s.loginChallenges)),
+		loginAttempts:         make(map[string]models.LoginAttempt, len(s.loginAttempts)),
+		securityEvents:        make(map[string]models.SecurityEvent, len(s.securityEvents)),
+		passwordHistory:       make(map[int64]models.PasswordHistoryEntry, len(s.passwordHistory)),
+		emailTokens:           make(map[string]models.EmailToken, len(s.emailTokens)),
+		oidcStates:            make(map[string]models.OIDCState, len(s.oidcStates)),
+		userIdentities:        make(map[identityKey]models.UserIdentity, len(s.userIdentities)),
+		roles:                 make(map[string]models.Role, len(s.roles)),
+		accountDeletions:      make(map[string]models.AccountDeletion, len(s.accountDeletions)),
+		dataExports:           make(map[string]models.DataExport, len(s.dataExports)),
+		nextContactID:         s.nextContactID,
+		nextAddressID:         s.nextAddressID,
+		nextPasswordHistoryID: s.nextPasswordHistoryID,
+	}
+	for k, v := range s.users {
+		snapshot.users[k] = v
+	}
+	for k, v := range s.contacts {
+		snapshot.contacts[k] = v
+	}
+	for k, v := range s.addresses {
+		snapshot.addresses[k] = v
+	}
+	for k, v := range s.sessions {
+		snapshot.sessions[k] = v
+	}
+	for k, v := range s.refreshTokens {
+		snapshot.refreshTokens[k] = v
+	}
+	for k, v := range s.apiKeys {
+		snapshot.apiKeys[k] = v
+	}
+	for k, v := range s.totpCredentials {
+		snapshot.totpCredentials[k] = v
+	}
+	for k, v := range s.recoveryCodes {
+		snapshot.recoveryCodes[k] = v
+	}
+	for k, v := range s.loginChallenges {
+		snapshot.loginChallenges[k] = v
+	}
+	for k, v := range s.loginAttempts {
+		snapshot.loginAttempts[k] = v
+	}
+	for k, v := range s.securityEvents {
+		snapshot.securityEvents[k] = v
+	}
+	for k, v := range s.passwordHistory {
+		snapshot.passwordHistory[k] = v
+	}
+	for k, v := range s.emailTokens {
+		snapshot.emailTokens[k] = v
+	}
+	for k, v := range s.oidcStates {
+		snapshot.oidcStates[k] = v
+	}
+	for k, v := range s.userIdentities {
+		snapshot.userIdentities[k] = v
+	}
+	for k, v := range s.roles {
+		snapshot.roles[k] = v
+	}
+	for k, v := range s.accountDeletions {
+		snapshot.accountDeletions[k] = v
+	}
+	for k, v := range s.dataExports {
+		snapshot.dataExports[k] = v
+	}
+	return snapshot
+}
+
+func (s *Store) restore(snapshot *Store) {
+	s.users = snapshot.users
+	s.contacts = snapshot.contacts
+	s.addresses = snapshot.addresses
+	s.sessions = snapshot.sessions
+	s.refreshTokens = snapshot.refreshTokens
+	s.apiKeys = snapshot.apiKeys
+	s.totpCredentials = snapshot.totpCredentials
+	s.recoveryCodes = snapshot.recoveryCodes
+	s.loginChallenges = snapshot.loginChallenges
+	s.loginAttempts = snapshot.loginAttempts
+	s.securityEvents = snapshot.securityEvents
+	s.passwordHistory = snapshot.passwordHistory
+	s.emailTokens = snapshot.emailTokens
+	s.oidcStates = snapshot.oidcStates
+	s.userIdentities = snapshot.userIdentities
+	s.roles = snapshot.roles
+	s.accountDeletions = snapshot.accountDeletions
+	s.dataExports = snapshot.dataExports
+	s.nextContactID = snapshot.nextContactID
+	s.nextAddressID = snapshot.nextAddressID
+	s.nextPasswordHistoryID = snapshot.nextPasswordHistoryID
+}
+
+// deleteSession deletes a session together with its refresh tokens, like the
+// cascading foreign key of the SQL schema. The caller holds the lock.
+func (s *Store) deleteSession(id string) {
+	delete(s.sessions, id)
+	for hash, token := range s.refreshTokens {
+		if token.SessionID == id {
+			delete(s.refreshTokens, hash)
+		}
+	}
+}
+
+// deleteUser deletes a user together with the rows that reference it, like
+// the cascading foreign keys of the SQL schema. The caller holds the lock and
+// made sure the user has no contacts.
+func (s *Store) deleteUser(key string) {
+	delete(s.users, key)
+	for id, session := range s.sessions {
+		if usernameKey(session.Username) == key {
+			s.deleteSession(id)
+		}
+	}
+	for id, apiKey := range s.apiKeys {
+		if usernameKey(apiKey.Username) == key {
+			delete(s.apiKeys, id)
+		}
+	}
+	delete(s.totpCredentials, key)
+	for hash, code := range s.recoveryCodes {
+		if usernameKey(code.Username) == key {
+			delete(s.recoveryCodes, hash)
+		}
+	}
+	for hash, challenge := range s.loginChallenges {
+		if usernameKey(challenge.Username) == key {
+			delete(s.loginChallenges, hash)
+		}
+	}
+	for id, event := range s.securityEvents {
+		if usernameKey(event.Username) == key {
+			delete(s.securityEvents, id)
+		}
+	}
+	for id, entry := range s.passwordHistory {
+		if usernameKey(entry.Username) == key {
+			delete(s.passwordHistory, id)
+		}
+	}
+	for hash, token := range s.emailTokens {
+		if usernameKey(token.Username) == key {
+			delete(s.emailTokens, hash)
+		}
+	}
+	for k, identity := range s.userIdentities {
+		if usernameKey(identity.Username) == key {
+			delete(s.userIdentities, k)
+		}
+	}
+	for id, export := range s.dataExports {
+		if usernameKey(export.Username) == key {
+			delete(s.dataExports, id)
+		}
+	}
+}
+
+// usernameKey mirrors the case-insensitive collation of the username columns.
+func usernameKey(username string) string {
+	return strings.ToLower(username)
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+// like reports whether value matches a SQL LIKE pattern, where "%" matches any
+// sequence and "_" matches a single character. Matching is case-insensitive
+// and a NULL value never matches.
+func like(value *string, pattern string) bool {
+	if value == nil {
+		return false
+	}
+	return likeMatch([]rune(strings.ToLower(*value)), []rune(strings.ToLower(pattern)))
+}
+
+func likeMatch(value, pattern []rune) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '%':
+			for len(pattern) > 0 && pattern[0] == '%' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(value); i++ {
+				if likeMatch(value[i:], pattern) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(value) == 0 {
+				return false
+			}
+		default:
+			if len(value) == 0 || value[0] != pattern[0] {
+				return false
+			}
+		}
+		value = value[1:]
+		pattern = pattern[1:]
+	}
+	return len(value) == 0
+}
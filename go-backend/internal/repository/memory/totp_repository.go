@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"time"
+)
+
+type totpRepository struct {
+	store *Store
+}
+
+func NewTOTPRepository(store *Store) repository.TOTPRepository {
+	return &totpRepository{
+		store: store,
+	}
+}
+
+func (r *totpRepository) FindByUsername(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	credential, ok := r.store.totpCredentials[usernameKey(username)]
+	if !ok {
+		return nil, nil
+	}
+
+	credential.ConfirmedAt = copyTime(credential.ConfirmedAt)
+	return &credential, nil
+}
+
+func (r *totpRepository) Create(ctx context.Context, credential *models.TOTPCredential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(credential.Username)]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.store.totpCredentials[usernameKey(credential.Username)]; ok {
+		return errDuplicateKey
+	}
+
+	stored := *credential
+	stored.ConfirmedAt = copyTime(credential.ConfirmedAt)
+	r.store.totpCredentials[usernameKey(credential.Username)] = stored
+	return nil
+}
+
+func (r *totpRepository) Confirm(ctx context.Context, username string, confirmedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	credential, ok := r.store.totpCredentials[usernameKey(username)]
+	if !ok {
+		return nil
+	}
+
+	credential.ConfirmedAt = &confirmedAt
+	r.store.totpCredentials[usernameKey(username)] = credential
+	return nil
+}
+
+func (r *totpRepository) UseStep(ctx context.Context, username string, step int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	credential, ok := r.store.totpCredentials[usernameKey(username)]
+	if !ok || credential.LastUsedStep >= step {
+		return false, nil
+	}
+
+	credential.LastUsedStep = step
+	r.store.totpCredentials[usernameKey(username)] = credential
+	return true, nil
+}
+
+func (r *totpRepository) Delete(ctx context.Context, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	delete(r.store.totpCredentials, usernameKey(username))
+	return nil
+}
+
+func (r *totpRepository) CreateRecoveryCodes(ctx context.Context, codes []models.RecoveryCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for _, code := range codes {
+		if _, ok := r.store.users[usernameKey(code.Username)]; !ok {
+			return errForeignKey
+		}
+		if _, ok := r.store.recoveryCodes[code.CodeHash]; ok {
+			return errDuplicateKey
+		}
+	}
+	for _, code := range codes {
+		r.store.recoveryCodes[code.CodeHash] = code
+	}
+	return nil
+}
+
+func (r *totpRepository) UseRecoveryCode(ctx context.Context, username string, codeHash string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	code, ok := r.store.recoveryCodes[codeHash]
+	if !ok || usernameKey(code.Username) != usernameKey(username) {
+		return false, nil
+	}
+
+	delete(r.store.recoveryCodes, codeHash)
+	return true, nil
+}
+
+func (r *totpRepository) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for hash, code := range r.store.recoveryCodes {
+		if usernameKey(code.Username) == usernameKey(username) {
+			delete(r.store.recoveryCodes, hash)
+		}
+	}
+	return nil
+}
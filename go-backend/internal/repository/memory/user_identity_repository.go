@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+)
+
+type userIdentityRepository struct {
+	store *Store
+}
+
+func NewUserIdentityRepository(store *Store) repository.UserIdentityRepository {
+	return &userIdentityRepository{
+		store: store,
+	}
+}
+
+func (r *userIdentityRepository) Find(ctx context.Context, issuer string, subject string) (*models.UserIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	identity, ok := r.store.userIdentities[identityKey{issuer: issuer, subject: subject}]
+	if !ok {
+		return nil, nil
+	}
+
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) FindByUsername(ctx context.Context, username string) ([]models.UserIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var identities []models.UserIdentity
+	for _, identity := range r.store.userIdentities {
+		if usernameKey(identity.Username) == usernameKey(username) {
+			identities = append(identities, identity)
+		}
+	}
+
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].CreatedAt.Before(identities[j].CreatedAt)
+	})
+
+	return identities, nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(identity.Username)]; !ok {
+		return errForeignKey
+	}
+	key := identityKey{issuer: identity.Issuer, subject: identity.Subject}
+	if _, ok := r.store.userIdentities[key]; ok {
+		return errDuplicateKey
+	}
+
+	r.store.userIdentities[key] = *identity
+	return nil
+}
@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"context"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{
+		store: store,
+	}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(user.Username)
+	if _, ok := r.store.users[key]; ok {
+		return errDuplicateKey
+	}
+	if user.Email != nil && r.findByEmail(*user.Email) != nil {
+		return errDuplicateKey
+	}
+	if _, ok := r.store.roles[user.Role]; !ok {
+		return errForeignKey
+	}
+
+	r.store.users[key] = copyUser(*user)
+	return nil
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	user, ok := r.store.users[usernameKey(username)]
+	if !ok {
+		return nil, nil
+	}
+
+	user = copyUser(user)
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, nil
+	}
+
+	found := copyUser(*user)
+	return &found, nil
+}
+
+// findByEmail mirrors the case-insensitive collation of the email column. The
+// caller holds the lock.
+func (r *userRepository) findByEmail(email string) *models.User {
+	for _, user := range r.store.users {
+		if user.Email != nil && strings.EqualFold(*user.Email, email) {
+			return &user
+		}
+	}
+	return nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(user.Username)
+	existing, ok := r.store.users[key]
+	if !ok {
+		return nil
+	}
+
+	updated := copyUser(*user)
+	updated.Username = existing.Username
+	r.store.users[key] = updated
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, username string, password string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(username)
+	user, ok := r.store.users[key]
+	if !ok {
+		return nil
+	}
+
+	user.Password = password
+	r.store.users[key] = user
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, username string, verifiedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(username)
+	user, ok := r.store.users[key]
+	if !ok {
+		return nil
+	}
+
+	user.EmailVerifiedAt = &verifiedAt
+	r.store.users[key] = user
+	return nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, username string, role string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(username)
+	user, ok := r.store.users[key]
+	if !ok {
+		return nil
+	}
+	if _, ok := r.store.roles[role]; !ok {
+		return errForeignKey
+	}
+
+	user.Role = role
+	r.store.users[key] = user
+	return nil
+}
+
+func (r *userRepository) SetDisabled(ctx context.Context, username string, disabledAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(username)
+	user, ok := r.store.users[key]
+	if !ok {
+		return nil
+	}
+
+	user.DisabledAt = copyTime(disabledAt)
+	r.store.users[key] = user
+	return nil
+}
+
+func (r *userRepository) SetDeletion(ctx context.Context, username string, requestedAt *time.Time, dueAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(username)
+	user, ok := r.store.users[key]
+	if !ok {
+		return nil
+	}
+
+	user.DeletionRequestedAt = copyTime(requestedAt)
+	user.DeletionDueAt = copyTime(dueAt)
+	r.store.users[key] = user
+	return nil
+}
+
+func (r *userRepository) FindDueForDeletion(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var users []models.User
+	for _, user := range r.store.users {
+		if user.DeletionDueAt != nil && !user.DeletionDueAt.After(now) {
+			users = append(users, copyUser(user))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].DeletionDueAt.Before(*users[j].DeletionDueAt)
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (r *userRepository) DeleteIfDue(ctx context.Context, username string, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key := usernameKey(username)
+	user, ok := r.store.users[key]
+	if !ok || user.DeletionDueAt == nil || user.DeletionDueAt.After(now) {
+		return false, nil
+	}
+	for _, contact := range r.store.contacts {
+		if usernameKey(contact.Username) == key {
+			return false, errForeignKey
+		}
+	}
+
+	r.store.deleteUser(key)
+	return true, nil
+}
+
+func (r *userRepository) Search(ctx context.Context, req *models.AdminUserSearchRequest) ([]models.User, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	var matches []models.User
+	for _, user := range r.store.users {
+		if req.Username != nil && *req.Username != "" && !like(&user.Username, "%"+*req.Username+"%") {
+			continue
+		}
+
+		if req.Name != nil && *req.Name != "" && !like(&user.Name, "%"+*req.Name+"%") {
+			continue
+		}
+
+		if req.Email != nil && *req.Email != "" && !like(user.Email, "%"+*req.Email+"%") {
+			continue
+		}
+
+		if req.Role != nil && *req.Role != "" && user.Role != *req.Role {
+			continue
+		}
+
+		if req.Disabled != nil && *req.Disabled != (user.DisabledAt != nil) {
+			continue
+		}
+
+		matches = append(matches, user)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return usernameKey(matches[i].Username) < usernameKey(matches[j].Username)
+	})
+
+	totalItems := len(matches)
+
+	offset := (req.Page - 1) * req.Size
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > totalItems {
+		offset = totalItems
+	}
+	end := offset + req.Size
+	if end > totalItems {
+		end = totalItems
+	}
+
+	var users []models.User
+	for _, user := range matches[offset:end] {
+		users = append(users, copyUser(user))
+	}
+
+	return users, totalItems, nil
+}
+
+func (r *userRepository) CountByUsername(ctx context.Context, username string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	if _, ok := r.store.users[usernameKey(username)]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (r *userRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.store.rlock(ctx)()
+
+	count := 0
+	for _, user := range r.store.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func copyUser(user models.User) models.User {
+	user.Email = copyString(user.Email)
+	user.EmailVerifiedAt = copyTime(user.EmailVerifiedAt)
+	user.DisabledAt = copyTime(user.DisabledAt)
+	user.DeletionRequestedAt = copyTime(user.DeletionRequestedAt)
+	user.DeletionDueAt = copyTime(user.DeletionDueAt)
+	return user
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"time"
+)
+
+// OIDCStateRepository stores the logins that were sent to the identity
+// provider.
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *models.OIDCState) error
+	// FindByHash returns the state with stateHash. Inside a transaction the
+	// row stays locked until it ends.
+	FindByHash(ctx context.Context, stateHash string) (*models.OIDCState, error)
+	Delete(ctx context.Context, stateHash string) error
+	// DeleteExpired deletes the states that expired at or before now.
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type oidcStateRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewOIDCStateRepository(db *database.DB) OIDCStateRepository {
+	return &oidcStateRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *oidcStateRepository) Create(ctx context.Context, state *models.OIDCState) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO oidc_states (state_hash, nonce, code_verifier, device_name, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, state.StateHash, state.Nonce, state.CodeVerifier, state.DeviceName,
+		state.CreatedAt, state.ExpiresAt)
+	return err
+}
+
+func (r *oidcStateRepository) FindByHash(ctx context.Context, stateHash string) (*models.OIDCState, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT state_hash, nonce, code_verifier, device_name, created_at, expires_at FROM oidc_states WHERE state_hash = ?` + r.dialect.ForUpdate()
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, stateHash)
+
+	var state models.OIDCState
+	err := row.Scan(&state.StateHash, &state.Nonce, &state.CodeVerifier, &state.DeviceName, &state.CreatedAt, &state.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *oidcStateRepository) Delete(ctx context.Context, stateHash string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM oidc_states WHERE state_hash = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, stateHash)
+	return err
+}
+
+func (r *oidcStateRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM oidc_states WHERE expires_at <= ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, now)
+	return err
+}
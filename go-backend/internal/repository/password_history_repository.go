@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+)
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, entry *models.PasswordHistoryEntry) error
+	// FindRecent returns the latest limit entries of username, newest first.
+	FindRecent(ctx context.Context, username string, limit int) ([]models.PasswordHistoryEntry, error)
+	// Prune deletes all but the latest keep entries of username.
+	Prune(ctx context.Context, username string, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewPasswordHistoryRepository(db *database.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, entry *models.PasswordHistoryEntry) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO password_history (username, password_hash, created_at) VALUES (?, ?, ?)`
+	id, err := r.dialect.Insert(ctx, r.db.Executor(ctx), query, entry.Username, entry.PasswordHash, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = id
+	return nil
+}
+
+func (r *passwordHistoryRepository) FindRecent(ctx context.Context, username string, limit int) ([]models.PasswordHistoryEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, username, password_hash, created_at FROM password_history WHERE username = ? ORDER BY id DESC LIMIT ?`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.PasswordHistoryEntry
+	for rows.Next() {
+		var entry models.PasswordHistoryEntry
+		if err := rows.Scan(&entry.ID, &entry.Username, &entry.PasswordHash, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *passwordHistoryRepository) Prune(ctx context.Context, username string, keep int) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	// MySQL does not allow LIMIT in an IN subquery, but in a derived table
+	query := `DELETE FROM password_history WHERE username = ? AND id NOT IN (
+		SELECT id FROM (SELECT id FROM password_history WHERE username = ? ORDER BY id DESC LIMIT ?) AS recent)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, username, username, keep)
+	return err
+}
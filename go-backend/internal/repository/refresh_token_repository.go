@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"time"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// MarkUsed records the use of an unused token and reports whether it was
+	// unused, so only one of several concurrent refreshes succeeds.
+	MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error)
+}
+
+type refreshTokenRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewRefreshTokenRepository(db *database.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO refresh_tokens (token_hash, session_id, username, created_at, expires_at, used_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, token.TokenHash, token.SessionID, token.Username, token.CreatedAt, token.ExpiresAt, token.UsedAt)
+	return err
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT token_hash, session_id, username, created_at, expires_at, used_at FROM refresh_tokens WHERE token_hash = ?`
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, tokenHash)
+
+	var token models.RefreshToken
+	err := row.Scan(&token.TokenHash, &token.SessionID, &token.Username, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, usedAt, tokenHash)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	return updated == 1, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"go-backend/internal/database"
+)
+
+// TxManager runs a unit of work atomically. Repository calls made with the
+// ctx passed to fn take part in the transaction, which is rolled back when fn
+// returns an error.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// WithinReadTx runs fn in a transaction that only reads and sees the
+	// data as of one point in time. fn must not write.
+	WithinReadTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repositories groups the repositories the application is built from.
+type Repositories struct {
+	User      UserRepository
+	Contact   ContactRepository
+	Address   AddressRepository
+	Session   SessionRepository
+	Refresh   RefreshTokenRepository
+	APIKey    APIKeyRepository
+	TOTP      TOTPRepository
+	Challenge LoginChallengeRepository
+	Attempt   LoginAttemptRepository
+	Event     SecurityEventRepository
+	Passwords PasswordHistoryRepository
+	Email     EmailTokenRepository
+	OIDCState OIDCStateRepository
+	Identity  UserIdentityRepository
+	Role      RoleRepository
+	Deletion  AccountDeletionRepository
+	Export    DataExportRepository
+	Tx        TxManager
+}
+
+// NewRepositories returns the SQL backed repositories using db.
+func NewRepositories(db *database.DB) *Repositories {
+	return &Repositories{
+		User:      NewUserRepository(db),
+		Contact:   NewContactRepository(db),
+		Address:   NewAddressRepository(db),
+		Session:   NewSessionRepository(db),
+		Refresh:   NewRefreshTokenRepository(db),
+		APIKey:    NewAPIKeyRepository(db),
+		TOTP:      NewTOTPRepository(db),
+		Challenge: NewLoginChallengeRepository(db),
+		Attempt:   NewLoginAttemptRepository(db),
+		Event:     NewSecurityEventRepository(db),
+		Passwords: NewPasswordHistoryRepository(db),
+		Email:     NewEmailTokenRepository(db),
+		OIDCState: NewOIDCStateRepository(db),
+		Identity:  NewUserIdentityRepository(db),
+		Role:      NewRoleRepository(db),
+		Deletion:  NewAccountDeletionRepository(db),
+		Export:    NewDataExportRepository(db),
+		Tx:        db,
+	}
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"strings"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.Role) error
+	FindByName(ctx context.Context, name string) (*models.Role, error)
+	// List returns every role ordered by name.
+	List(ctx context.Context) ([]models.Role, error)
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+}
+
+type roleRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewRoleRepository(db *database.DB) RoleRepository {
+	return &roleRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+const roleColumns = `name, description, permissions, created_at, updated_at`
+
+// scanRole reads a role, whose permissions are stored space-separated.
+func scanRole(row scanner) (*models.Role, error) {
+	var role models.Role
+	var permissions string
+	err := row.Scan(&role.Name, &role.Description, &permissions, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = strings.Fields(permissions)
+	return &role, nil
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO roles (` + roleColumns + `) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, role.Name, role.Description, strings.Join(role.Permissions, " "),
+		role.CreatedAt, role.UpdatedAt)
+	return err
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE name = ?`
+	role, err := scanRole(r.db.Executor(ctx).QueryRowContext(ctx, query, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]models.Role, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + roleColumns + ` FROM roles ORDER BY name`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *roleRepository) Update(ctx context.Context, role *models.Role) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE roles SET description = ?, permissions = ?, updated_at = ? WHERE name = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, role.Description, strings.Join(role.Permissions, " "),
+		role.UpdatedAt, role.Name)
+	return err
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM roles WHERE name = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, name)
+	return err
+}
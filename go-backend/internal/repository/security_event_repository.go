@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+)
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *models.SecurityEvent) error
+	// FindByUsername returns the latest limit events of username, newest
+	// first.
+	FindByUsername(ctx context.Context, username string, limit int) ([]models.SecurityEvent, error)
+}
+
+type securityEventRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewSecurityEventRepository(db *database.DB) SecurityEventRepository {
+	return &securityEventRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *securityEventRepository) Create(ctx context.Context, event *models.SecurityEvent) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO security_events (id, username, type, ip_address, user_agent, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, event.ID, event.Username, event.Type, event.IPAddress,
+		event.UserAgent, event.CreatedAt)
+	return err
+}
+
+func (r *securityEventRepository) FindByUsername(ctx context.Context, username string, limit int) ([]models.SecurityEvent, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, username, type, ip_address, user_agent, created_at FROM security_events WHERE username = ? ORDER BY created_at DESC LIMIT ?`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.SecurityEvent
+	for rows.Next() {
+		var event models.SecurityEvent
+		if err := rows.Scan(&event.ID, &event.Username, &event.Type, &event.IPAddress, &event.UserAgent, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
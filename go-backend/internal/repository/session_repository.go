@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"strings"
+	"time"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id string, username string) (*models.Session, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	// FindByUsername returns the sessions of username that expire after now
+	// and were used after idleSince, most recently used first.
+	FindByUsername(ctx context.Context, username string, now time.Time, idleSince time.Time) ([]models.Session, error)
+	Touch(ctx context.Context, id string, lastUsedAt time.Time) error
+	// UpdateScopes replaces the scopes of every session of username.
+	UpdateScopes(ctx context.Context, username string, scopes []string) error
+	Delete(ctx context.Context, id string, username string) error
+	// DeleteOthers deletes every session of username except keepID and
+	// returns how many were deleted.
+	DeleteOthers(ctx context.Context, username string, keepID string) (int, error)
+	// DeleteExpired deletes the sessions of username that expired at or
+	// before now or were last used at or before idleSince.
+	DeleteExpired(ctx context.Context, username string, now time.Time, idleSince time.Time) error
+}
+
+type sessionRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewSessionRepository(db *database.DB) SessionRepository {
+	return &sessionRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+const sessionColumns = `id, username, token_hash, device_name, user_agent, ip_address, created_at, last_used_at, expires_at, scopes`
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row scanner) (*models.Session, error) {
+	var session models.Session
+	var scopes string
+	err := row.Scan(&session.ID, &session.Username, &session.TokenHash, &session.DeviceName, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &scopes)
+	if err != nil {
+		return nil, err
+	}
+	session.Scopes = strings.Fields(scopes)
+	return &session, nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO sessions (` + sessionColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, session.ID, session.Username, session.TokenHash, session.DeviceName,
+		session.UserAgent, session.IPAddress, session.CreatedAt, session.LastUsedAt, session.ExpiresAt, strings.Join(session.Scopes, " "))
+	return err
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id string, username string) (*models.Session, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ? AND username = ?`
+	session, err := scanSession(r.db.Executor(ctx).QueryRowContext(ctx, query, id, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token_hash = ?`
+	session, err := scanSession(r.db.Executor(ctx).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) FindByUsername(ctx context.Context, username string, now time.Time, idleSince time.Time) ([]models.Session, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE username = ? AND expires_at > ? AND last_used_at > ? ORDER BY last_used_at DESC`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username, now, idleSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET last_used_at = ? WHERE id = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, lastUsedAt, id)
+	return err
+}
+
+func (r *sessionRepository) UpdateScopes(ctx context.Context, username string, scopes []string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET scopes = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, strings.Join(scopes, " "), username)
+	return err
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, id string, username string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM sessions WHERE id = ? AND username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, id, username)
+	return err
+}
+
+func (r *sessionRepository) DeleteOthers(ctx context.Context, username string, keepID string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM sessions WHERE username = ? AND id <> ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username, keepID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+func (r *sessionRepository) DeleteExpired(ctx context.Context, username string, now time.Time, idleSince time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM sessions WHERE username = ? AND (expires_at <= ? OR last_used_at <= ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, username, now, idleSince)
+	return err
+}
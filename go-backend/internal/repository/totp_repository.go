@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+	"time"
+)
+
+// TOTPRepository stores the TOTP credentials of users and their recovery
+// codes.
+type TOTPRepository interface {
+	FindByUsername(ctx context.Context, username string) (*models.TOTPCredential, error)
+	Create(ctx context.Context, credential *models.TOTPCredential) error
+	Confirm(ctx context.Context, username string, confirmedAt time.Time) error
+	// UseStep records step as the last used time step unless a code of that
+	// or a later step was accepted before, and reports whether it did.
+	UseStep(ctx context.Context, username string, step int64) (bool, error)
+	Delete(ctx context.Context, username string) error
+
+	CreateRecoveryCodes(ctx context.Context, codes []models.RecoveryCode) error
+	// UseRecoveryCode deletes the code and reports whether it existed.
+	UseRecoveryCode(ctx context.Context, username string, codeHash string) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+}
+
+type totpRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewTOTPRepository(db *database.DB) TOTPRepository {
+	return &totpRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *totpRepository) FindByUsername(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT username, secret, last_used_step, created_at, confirmed_at FROM totp_credentials WHERE username = ?`
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, username)
+
+	var credential models.TOTPCredential
+	err := row.Scan(&credential.Username, &credential.Secret, &credential.LastUsedStep, &credential.CreatedAt, &credential.ConfirmedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+func (r *totpRepository) Create(ctx context.Context, credential *models.TOTPCredential) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO totp_credentials (username, secret, last_used_step, created_at, confirmed_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, credential.Username, credential.Secret, credential.LastUsedStep,
+		credential.CreatedAt, credential.ConfirmedAt)
+	return err
+}
+
+func (r *totpRepository) Confirm(ctx context.Context, username string, confirmedAt time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE totp_credentials SET confirmed_at = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, confirmedAt, username)
+	return err
+}
+
+func (r *totpRepository) UseStep(ctx context.Context, username string, step int64) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE totp_credentials SET last_used_step = ? WHERE username = ? AND last_used_step < ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, step, username, step)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	return updated == 1, err
+}
+
+func (r *totpRepository) Delete(ctx context.Context, username string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM totp_credentials WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, username)
+	return err
+}
+
+func (r *totpRepository) CreateRecoveryCodes(ctx context.Context, codes []models.RecoveryCode) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO recovery_codes (code_hash, username, created_at) VALUES (?, ?, ?)`
+	for _, code := range codes {
+		if _, err := r.db.Executor(ctx).ExecContext(ctx, query, code.CodeHash, code.Username, code.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *totpRepository) UseRecoveryCode(ctx context.Context, username string, codeHash string) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM recovery_codes WHERE username = ? AND code_hash = ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return deleted == 1, err
+}
+
+func (r *totpRepository) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM recovery_codes WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, username)
+	return err
+}
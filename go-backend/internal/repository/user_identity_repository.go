@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-backend/internal/database"
+	"go-backend/internal/models"
+)
+
+// UserIdentityRepository stores which identity provider accounts log in as
+// which user.
+type UserIdentityRepository interface {
+	Find(ctx context.Context, issuer string, subject string) (*models.UserIdentity, error)
+	// FindByUsername returns the identities linked to username, oldest
+	// first.
+	FindByUsername(ctx context.Context, username string) ([]models.UserIdentity, error)
+	Create(ctx context.Context, identity *models.UserIdentity) error
+}
+
+type userIdentityRepository struct {
+	db      *database.DB
+	dialect database.Dialect
+}
+
+func NewUserIdentityRepository(db *database.DB) UserIdentityRepository {
+	return &userIdentityRepository{
+		db:      db,
+		dialect: db.Dialect,
+	}
+}
+
+func (r *userIdentityRepository) Find(ctx context.Context, issuer string, subject string) (*models.UserIdentity, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT issuer, subject, username, email, created_at FROM user_identities WHERE issuer = ? AND subject = ?`
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, issuer, subject)
+
+	var identity models.UserIdentity
+	err := row.Scan(&identity.Issuer, &identity.Subject, &identity.Username, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) FindByUsername(ctx context.Context, username string) ([]models.UserIdentity, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT issuer, subject, username, email, created_at FROM user_identities WHERE username = ? ORDER BY created_at`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []models.UserIdentity
+	for rows.Next() {
+		var identity models.UserIdentity
+		if err := rows.Scan(&identity.Issuer, &identity.Subject, &identity.Username, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO user_identities (issuer, subject, username, email, created_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, identity.Issuer, identity.Subject, identity.Username,
+		identity.Email, identity.CreatedAt)
+	return err
+}
@@ -1,40 +1,83 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"go-backend/internal/database"
 	"go-backend/internal/models"
+	"strings"
+	"time"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByUsername(username string) (*models.User, error)
-	Update(user *models.User) error
-	CountByUsername(username string) (int, error)
+	Create(ctx context.Context, user *models.User) error
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, username string, password string) error
+	// MarkEmailVerified records that username confirmed the email address it
+	// currently has.
+	MarkEmailVerified(ctx context.Context, username string, verifiedAt time.Time) error
+	UpdateRole(ctx context.Context, username string, role string) error
+	// SetDisabled disables username at disabledAt, or enables it when
+	// disabledAt is nil.
+	SetDisabled(ctx context.Context, username string, disabledAt *time.Time) error
+	// SetDeletion schedules the deletion of username, or cancels it when
+	// requestedAt and dueAt are nil.
+	SetDeletion(ctx context.Context, username string, requestedAt *time.Time, dueAt *time.Time) error
+	// FindDueForDeletion returns up to limit users whose deletion is due at
+	// now, the longest overdue first.
+	FindDueForDeletion(ctx context.Context, now time.Time, limit int) ([]models.User, error)
+	// DeleteIfDue deletes username if its deletion is due at now and reports
+	// whether it did. The rows of the user in other tables go with it, except
+	// contacts, which have to be deleted first.
+	DeleteIfDue(ctx context.Context, username string, now time.Time) (bool, error)
+	// Search returns a page of the users matching req ordered by username,
+	// and how many match in total.
+	Search(ctx context.Context, req *models.AdminUserSearchRequest) ([]models.User, int, error)
+	CountByUsername(ctx context.Context, username string) (int, error)
+	CountByRole(ctx context.Context, role string) (int, error)
 }
 
 type userRepository struct {
-	db *sql.DB
+	db      *database.DB
+	dialect database.Dialect
 }
 
-func NewUserRepository() UserRepository {
+func NewUserRepository(db *database.DB) UserRepository {
 	return &userRepository{
-		db: database.DB,
+		db:      db,
+		dialect: db.Dialect,
 	}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	query := `INSERT INTO users (username, password, name) VALUES (?, ?, ?)`
-	_, err := r.db.Exec(query, user.Username, user.Password, user.Name)
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO users (username, password, name, email, email_verified_at, role) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, user.Username, user.Password, user.Name, user.Email, user.EmailVerifiedAt, user.Role)
 	return err
 }
 
-func (r *userRepository) FindByUsername(username string) (*models.User, error) {
-	query := `SELECT username, password, name, token FROM users WHERE username = ?`
-	row := r.db.QueryRow(query, username)
+const userColumns = `username, password, name, email, email_verified_at, role, disabled_at, deletion_requested_at, deletion_due_at`
 
+func scanUser(row scanner) (*models.User, error) {
 	var user models.User
-	err := row.Scan(&user.Username, &user.Password, &user.Name, &user.Token)
+	err := row.Scan(&user.Username, &user.Password, &user.Name, &user.Email, &user.EmailVerifiedAt, &user.Role, &user.DisabledAt, &user.DeletionRequestedAt, &user.DeletionDueAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
+	user, err := scanUser(r.db.Executor(ctx).QueryRowContext(ctx, query, username))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -42,18 +85,203 @@ func (r *userRepository) FindByUsername(username string) (*models.User, error) {
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
 }
 
-func (r *userRepository) Update(user *models.User) error {
-	query := `UPDATE users SET password = ?, name = ?, token = ? WHERE username = ?`
-	_, err := r.db.Exec(query, user.Password, user.Name, user.Token, user.Username)
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ?`
+	user, err := scanUser(r.db.Executor(ctx).QueryRowContext(ctx, query, email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET password = ?, name = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, user.Password, user.Name, user.Username)
 	return err
 }
 
-func (r *userRepository) CountByUsername(username string) (int, error) {
+func (r *userRepository) UpdatePassword(ctx context.Context, username string, password string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET password = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, password, username)
+	return err
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, username string, verifiedAt time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET email_verified_at = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, verifiedAt, username)
+	return err
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, username string, role string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET role = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, role, username)
+	return err
+}
+
+func (r *userRepository) SetDisabled(ctx context.Context, username string, disabledAt *time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET disabled_at = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, disabledAt, username)
+	return err
+}
+
+func (r *userRepository) SetDeletion(ctx context.Context, username string, requestedAt *time.Time, dueAt *time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET deletion_requested_at = ?, deletion_due_at = ? WHERE username = ?`
+	_, err := r.db.Executor(ctx).ExecContext(ctx, query, requestedAt, dueAt, username)
+	return err
+}
+
+func (r *userRepository) FindDueForDeletion(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE deletion_due_at <= ? ORDER BY deletion_due_at LIMIT ?`
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *userRepository) DeleteIfDue(ctx context.Context, username string, now time.Time) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE username = ? AND deletion_due_at <= ?`
+	result, err := r.db.Executor(ctx).ExecContext(ctx, query, username, now)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return deleted == 1, err
+}
+
+func (r *userRepository) Search(ctx context.Context, req *models.AdminUserSearchRequest) ([]models.User, int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	// Build WHERE clause
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if req.Username != nil && *req.Username != "" {
+		conditions = append(conditions, r.dialect.Like("username"))
+		args = append(args, "%"+*req.Username+"%")
+	}
+
+	if req.Name != nil && *req.Name != "" {
+		conditions = append(conditions, r.dialect.Like("name"))
+		args = append(args, "%"+*req.Name+"%")
+	}
+
+	if req.Email != nil && *req.Email != "" {
+		conditions = append(conditions, r.dialect.Like("email"))
+		args = append(args, "%"+*req.Email+"%")
+	}
+
+	if req.Role != nil && *req.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, *req.Role)
+	}
+
+	if req.Disabled != nil {
+		if *req.Disabled {
+			conditions = append(conditions, "disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "disabled_at IS NULL")
+		}
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	// Count total items
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users WHERE %s", whereClause)
+	var totalItems int
+	err := r.db.Executor(ctx).QueryRowContext(ctx, countQuery, args...).Scan(&totalItems)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get users with pagination
+	offset := (req.Page - 1) * req.Size
+	query := fmt.Sprintf("SELECT %s FROM users WHERE %s ORDER BY username LIMIT ? OFFSET ?", userColumns, whereClause)
+	args = append(args, req.Size, offset)
+
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, totalItems, rows.Err()
+}
+
+func (r *userRepository) CountByUsername(ctx context.Context, username string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM users WHERE username = ?`
-	row := r.db.QueryRow(query, username)
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, username)
+
+	var count int
+	err := row.Scan(&count)
+	return count, err
+}
+
+func (r *userRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM users WHERE role = ?`
+	row := r.db.Executor(ctx).QueryRowContext(ctx, query, role)
 
 	var count int
 	err := row.Scan(&count)
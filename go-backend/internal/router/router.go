@@ -1,63 +1,165 @@
 package router
 
 import (
+	"go-backend/internal/auth"
 	"go-backend/internal/handler"
+	"go-backend/internal/metrics"
 	"go-backend/internal/middleware"
-	"go-backend/internal/repository"
-	"go-backend/internal/service"
+	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-func SetupRoutes() *mux.Router {
-	r := mux.NewRouter()
-
-	// Initialize repositories
-	userRepo := repository.NewUserRepository()
-	contactRepo := repository.NewContactRepository()
-	addressRepo := repository.NewAddressRepository()
+// Dependencies are the handlers and middleware the routes are mapped to.
+type Dependencies struct {
+	UserHandler          *handler.UserHandler
+	ContactHandler       *handler.ContactHandler
+	AddressHandler       *handler.AddressHandler
+	SessionHandler       *handler.SessionHandler
+	APIKeyHandler        *handler.APIKeyHandler
+	TwoFactorHandler     *handler.TwoFactorHandler
+	SecurityEventHandler *handler.SecurityEventHandler
+	EmailHandler         *handler.EmailHandler
+	AdminHandler         *handler.AdminHandler
+	ExportHandler        *handler.ExportHandler
+	// TokenHandler is nil unless the jwt auth mode is configured.
+	TokenHandler *handler.TokenHandler
+	// OIDCHandler is nil unless OIDC login is enabled.
+	OIDCHandler    *handler.OIDCHandler
+	HealthHandler  *handler.HealthHandler
+	AuthMiddleware *middleware.AuthMiddleware
+	Tracing        mux.MiddlewareFunc
+	ClientIP       mux.MiddlewareFunc
+	RequestLogger  *middleware.RequestLogger
+	// Metrics is nil when metrics are disabled.
+	Metrics     *metrics.Metrics
+	MetricsPath string
+}
 
-	// Initialize services
-	userService := service.NewUserService(userRepo)
-	contactService := service.NewContactService(contactRepo)
-	addressService := service.NewAddressService(addressRepo, contactRepo)
+func SetupRoutes(deps *Dependencies) *mux.Router {
+	r := mux.NewRouter()
 
-	// Initialize handlers
-	userHandler := handler.NewUserHandler(userService)
-	contactHandler := handler.NewContactHandler(contactService)
-	addressHandler := handler.NewAddressHandler(addressService)
-	healthHandler := handler.NewHealthHandler()
+	userHandler := deps.UserHandler
+	contactHandler := deps.ContactHandler
+	addressHandler := deps.AddressHandler
+	sessionHandler := deps.SessionHandler
+	apiKeyHandler := deps.APIKeyHandler
+	twoFactorHandler := deps.TwoFactorHandler
+	securityEventHandler := deps.SecurityEventHandler
+	emailHandler := deps.EmailHandler
+	adminHandler := deps.AdminHandler
+	exportHandler := deps.ExportHandler
+	healthHandler := deps.HealthHandler
+	authMiddleware := deps.AuthMiddleware
 
-	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware()
+	notFound := http.NotFoundHandler()
+	methodNotAllowed := methodNotAllowedHandler()
+	if deps.Metrics != nil {
+		r.Use(deps.Metrics.Middleware)
+		notFound = deps.Metrics.UnmatchedHandler(notFound)
+		methodNotAllowed = deps.Metrics.UnmatchedHandler(methodNotAllowed)
+		r.Handle(deps.MetricsPath, deps.Metrics.Handler()).Methods("GET")
+	}
+	r.Use(deps.Tracing)
+	r.Use(deps.ClientIP)
+	r.Use(deps.RequestLogger.Middleware)
+	r.Use(middleware.StripIdentityHeaders)
+	r.NotFoundHandler = deps.ClientIP(deps.RequestLogger.Middleware(notFound))
+	r.MethodNotAllowedHandler = deps.ClientIP(deps.RequestLogger.Middleware(methodNotAllowed))
 
 	// Public routes
 	r.HandleFunc("/api/users", userHandler.Register).Methods("POST")
 	r.HandleFunc("/api/users/login", userHandler.Login).Methods("POST")
+	r.HandleFunc("/api/users/login/2fa", twoFactorHandler.CompleteLogin).Methods("POST")
+	r.HandleFunc("/api/users/password/forgot", emailHandler.ForgotPassword).Methods("POST")
+	r.HandleFunc("/api/users/password/reset", emailHandler.ResetPassword).Methods("POST")
+	r.HandleFunc("/api/users/email/verify", emailHandler.Verify).Methods("POST")
+	// Download links of data exports are signed instead of authenticated
+	r.HandleFunc("/api/exports/{exportId:[0-9a-f-]{36}}", exportHandler.Download).Methods("GET")
 	r.HandleFunc("/ping", healthHandler.Ping).Methods("GET")
+	r.HandleFunc("/healthz", healthHandler.Liveness).Methods("GET")
+	r.HandleFunc("/readyz", healthHandler.Readiness).Methods("GET")
+	if tokenHandler := deps.TokenHandler; tokenHandler != nil {
+		r.HandleFunc("/api/users/refresh", tokenHandler.Refresh).Methods("POST")
+		r.HandleFunc("/.well-known/jwks.json", tokenHandler.JWKS).Methods("GET")
+	}
+	if oidcHandler := deps.OIDCHandler; oidcHandler != nil {
+		r.HandleFunc("/api/users/oidc/authorize", oidcHandler.Authorize).Methods("POST")
+		r.HandleFunc("/api/users/oidc/callback", oidcHandler.Callback).Methods("POST")
+	}
 
 	// Protected routes
 	protected := r.PathPrefix("/api").Subrouter()
 	protected.Use(authMiddleware.RequireAuth)
 
+	// Every route requires a scope, API keys are limited to the scopes they
+	// were created with
+	scoped := middleware.RequireScope
+
 	// User routes
-	protected.HandleFunc("/users/current", userHandler.GetCurrent).Methods("GET")
-	protected.HandleFunc("/users/current", userHandler.Update).Methods("PATCH")
-	protected.HandleFunc("/users/logout", userHandler.Logout).Methods("DELETE")
+	protected.Handle("/users/current", scoped(auth.ScopeUserRead, userHandler.GetCurrent)).Methods("GET")
+	protected.Handle("/users/current", scoped(auth.ScopeUserWrite, userHandler.Update)).Methods("PATCH")
+	protected.Handle("/users/current", scoped(auth.ScopeUserAdmin, userHandler.Delete)).Methods("DELETE")
+	protected.Handle("/users/current/deletion", scoped(auth.ScopeUserAdmin, userHandler.CancelDeletion)).Methods("DELETE")
+	protected.Handle("/users/current/password", scoped(auth.ScopeUserAdmin, userHandler.ChangePassword)).Methods("POST")
+	protected.Handle("/users/current/email/verification", scoped(auth.ScopeUserAdmin, emailHandler.SendVerification)).Methods("POST")
+	protected.Handle("/users/logout", scoped(auth.ScopeUserAdmin, userHandler.Logout)).Methods("DELETE")
+
+	// Session routes
+	protected.Handle("/users/current/sessions", scoped(auth.ScopeUserAdmin, sessionHandler.List)).Methods("GET")
+	protected.Handle("/users/current/sessions/others", scoped(auth.ScopeUserAdmin, sessionHandler.RevokeOthers)).Methods("DELETE")
+	protected.Handle("/users/current/sessions/{sessionId:[0-9a-f-]{36}}", scoped(auth.ScopeUserAdmin, sessionHandler.Revoke)).Methods("DELETE")
+
+	// API key routes
+	protected.Handle("/users/current/api-keys", scoped(auth.ScopeUserAdmin, apiKeyHandler.Create)).Methods("POST")
+	protected.Handle("/users/current/api-keys", scoped(auth.ScopeUserAdmin, apiKeyHandler.List)).Methods("GET")
+	protected.Handle("/users/current/api-keys/{keyId:[0-9a-f-]{36}}", scoped(auth.ScopeUserAdmin, apiKeyHandler.Revoke)).Methods("DELETE")
+
+	// Two-factor authentication routes
+	protected.Handle("/users/current/2fa", scoped(auth.ScopeUserAdmin, twoFactorHandler.Enroll)).Methods("POST")
+	protected.Handle("/users/current/2fa/verify", scoped(auth.ScopeUserAdmin, twoFactorHandler.Confirm)).Methods("POST")
+	protected.Handle("/users/current/2fa/recovery-codes", scoped(auth.ScopeUserAdmin, twoFactorHandler.RegenerateRecoveryCodes)).Methods("POST")
+	protected.Handle("/users/current/2fa", scoped(auth.ScopeUserAdmin, twoFactorHandler.Disable)).Methods("DELETE")
+
+	// Security event routes
+	protected.Handle("/users/current/security-events", scoped(auth.ScopeUserRead, securityEventHandler.List)).Methods("GET")
+
+	// Data export routes
+	protected.Handle("/users/current/export", scoped(auth.ScopeUserAdmin, exportHandler.Request)).Methods("GET")
 
 	// Contact routes
-	protected.HandleFunc("/contacts", contactHandler.Create).Methods("POST")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}", contactHandler.GetByID).Methods("GET")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}", contactHandler.Update).Methods("PUT")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}", contactHandler.Delete).Methods("DELETE")
-	protected.HandleFunc("/contacts", contactHandler.Search).Methods("GET")
+	protected.Handle("/contacts", scoped(auth.ScopeContactsWrite, contactHandler.Create)).Methods("POST")
+	protected.Handle("/contacts/{contactId:[0-9]+}", scoped(auth.ScopeContactsRead, contactHandler.GetByID)).Methods("GET")
+	protected.Handle("/contacts/{contactId:[0-9]+}", scoped(auth.ScopeContactsWrite, contactHandler.Update)).Methods("PUT")
+	protected.Handle("/contacts/{contactId:[0-9]+}", scoped(auth.ScopeContactsWrite, contactHandler.Delete)).Methods("DELETE")
+	protected.Handle("/contacts", scoped(auth.ScopeContactsRead, contactHandler.Search)).Methods("GET")
 
 	// Address routes
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}/addresses", addressHandler.Create).Methods("POST")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}/addresses/{addressId:[0-9]+}", addressHandler.GetByID).Methods("GET")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}/addresses/{addressId:[0-9]+}", addressHandler.Update).Methods("PUT")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}/addresses/{addressId:[0-9]+}", addressHandler.Delete).Methods("DELETE")
-	protected.HandleFunc("/contacts/{contactId:[0-9]+}/addresses", addressHandler.GetByContactID).Methods("GET")
+	protected.Handle("/contacts/{contactId:[0-9]+}/addresses", scoped(auth.ScopeAddressesWrite, addressHandler.Create)).Methods("POST")
+	protected.Handle("/contacts/{contactId:[0-9]+}/addresses/{addressId:[0-9]+}", scoped(auth.ScopeAddressesRead, addressHandler.GetByID)).Methods("GET")
+	protected.Handle("/contacts/{contactId:[0-9]+}/addresses/{addressId:[0-9]+}", scoped(auth.ScopeAddressesWrite, addressHandler.Update)).Methods("PUT")
+	protected.Handle("/contacts/{contactId:[0-9]+}/addresses/{addressId:[0-9]+}", scoped(auth.ScopeAddressesWrite, addressHandler.Delete)).Methods("DELETE")
+	protected.Handle("/contacts/{contactId:[0-9]+}/addresses", scoped(auth.ScopeAddressesRead, addressHandler.GetByContactID)).Methods("GET")
+
+	// Admin routes need the admin scope and a permission of the user's role
+	permitted := middleware.RequirePermission
+	protected.Handle("/admin/users", scoped(auth.ScopeAdmin, permitted(auth.PermissionUsersRead, adminHandler.SearchUsers))).Methods("GET")
+	protected.Handle("/admin/users/{username}", scoped(auth.ScopeAdmin, permitted(auth.PermissionUsersRead, adminHandler.GetUser))).Methods("GET")
+	protected.Handle("/admin/users/{username}/disable", scoped(auth.ScopeAdmin, permitted(auth.PermissionUsersWrite, adminHandler.DisableUser))).Methods("POST")
+	protected.Handle("/admin/users/{username}/enable", scoped(auth.ScopeAdmin, permitted(auth.PermissionUsersWrite, adminHandler.EnableUser))).Methods("POST")
+	protected.Handle("/admin/users/{username}/sessions", scoped(auth.ScopeAdmin, permitted(auth.PermissionUsersWrite, adminHandler.LogoutUser))).Methods("DELETE")
+	protected.Handle("/admin/users/{username}/password", scoped(auth.ScopeAdmin, permitted(auth.PermissionUsersWrite, adminHandler.SetUserPassword))).Methods("POST")
+	protected.Handle("/admin/users/{username}/role", scoped(auth.ScopeAdmin, permitted(auth.PermissionRolesWrite, adminHandler.SetUserRole))).Methods("PUT")
+	protected.Handle("/admin/roles", scoped(auth.ScopeAdmin, permitted(auth.PermissionRolesRead, adminHandler.ListRoles))).Methods("GET")
+	protected.Handle("/admin/roles", scoped(auth.ScopeAdmin, permitted(auth.PermissionRolesWrite, adminHandler.CreateRole))).Methods("POST")
+	protected.Handle("/admin/roles/{role}", scoped(auth.ScopeAdmin, permitted(auth.PermissionRolesWrite, adminHandler.UpdateRole))).Methods("PATCH")
+	protected.Handle("/admin/roles/{role}", scoped(auth.ScopeAdmin, permitted(auth.PermissionRolesWrite, adminHandler.DeleteRole))).Methods("DELETE")
 
 	return r
+}
+
+func methodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
 }
\ No newline at end of file
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/password"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// purgeBatchSize bounds how many accounts one PurgeDue call deletes.
+const purgeBatchSize = 100
+
+// errDeletionCancelled rolls back a purge whose deletion was cancelled after
+// the account was found due.
+var errDeletionCancelled = errors.New("deletion was cancelled")
+
+// AccountDeletionService deletes accounts at the request of their owner. A
+// deletion is only scheduled at first, the owner can cancel it until the
+// grace period ends. Afterwards the account is purged with its contacts,
+// addresses, sessions, data exports and everything else it owns, leaving an
+// audit record that does not identify the user.
+type AccountDeletionService interface {
+	// Schedule schedules the deletion of username after checking its
+	// password and second factor again.
+	Schedule(ctx context.Context, username string, req *models.AccountDeleteRequest) (*models.AccountDeletionResponse, error)
+	Cancel(ctx context.Context, username string, userAgent string, ipAddress string) error
+	// PurgeDue purges the accounts whose grace period ended and returns how
+	// many it purged.
+	PurgeDue(ctx context.Context) (int, error)
+}
+
+type accountDeletionService struct {
+	userRepo     repository.UserRepository
+	contactRepo  repository.ContactRepository
+	addressRepo  repository.AddressRepository
+	sessionRepo  repository.SessionRepository
+	attemptRepo  repository.LoginAttemptRepository
+	deletionRepo repository.AccountDeletionRepository
+	txManager    repository.TxManager
+	exports      DataExportService
+	twoFactor    TwoFactorService
+	throttle     LoginThrottle
+	events       SecurityEventService
+	hasher       *password.Hasher
+	clock        clock.Clock
+	gracePeriod  time.Duration
+}
+
+func NewAccountDeletionService(userRepo repository.UserRepository, contactRepo repository.ContactRepository, addressRepo repository.AddressRepository, sessionRepo repository.SessionRepository, attemptRepo repository.LoginAttemptRepository, deletionRepo repository.AccountDeletionRepository, txManager repository.TxManager, exports DataExportService, twoFactor TwoFactorService, throttle LoginThrottle, events SecurityEventService, hasher *password.Hasher, clk clock.Clock, gracePeriod time.Duration) AccountDeletionService {
+	return &accountDeletionService{
+		userRepo:     userRepo,
+		contactRepo:  contactRepo,
+		addressRepo:  addressRepo,
+		sessionRepo:  sessionRepo,
+		attemptRepo:  attemptRepo,
+		deletionRepo: deletionRepo,
+		txManager:    txManager,
+		exports:      exports,
+		twoFactor:    twoFactor,
+		throttle:     throttle,
+		events:       events,
+		hasher:       hasher,
+		clock:        clk,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+func (s *accountDeletionService) Schedule(ctx context.Context, username string, req *models.AccountDeleteRequest) (*models.AccountDeletionResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user is not found")
+	}
+	if user.DeletionDueAt != nil {
+		return nil, errors.New("Account is already scheduled for deletion")
+	}
+
+	// Wrong passwords count as failed logins, so the session or API key
+	// making the request cannot guess faster than a login
+	login := &models.UserLoginRequest{
+		Username:  user.Username,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	}
+	if err := s.throttle.Attempt(ctx, login); err != nil {
+		return nil, err
+	}
+	if !s.hasher.Verify(req.Password, user.Password) {
+		logger.FromContext(ctx).Info("Account deletion refused, wrong password")
+		if err := s.throttle.Failed(ctx, login, user); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("Password is wrong")
+	}
+	if err := s.throttle.Passed(ctx, login); err != nil {
+		return nil, err
+	}
+	if err := s.twoFactor.Reauthenticate(ctx, login, req.Code); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	dueAt := now.Add(s.gracePeriod)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.SetDeletion(ctx, user.Username, &now, &dueAt); err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, &models.SecurityEvent{
+			Username:  user.Username,
+			Type:      models.SecurityEventDeletionScheduled,
+			IPAddress: req.IPAddress,
+			UserAgent: req.UserAgent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithField("deletion_due_at", dueAt).Info("Account deletion scheduled")
+	return &models.AccountDeletionResponse{
+		DeletionDueAt: dueAt,
+	}, nil
+}
+
+func (s *accountDeletionService) Cancel(ctx context.Context, username string, userAgent string, ipAddress string) error {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.FindByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("user is not found")
+		}
+		if user.DeletionDueAt == nil {
+			return errors.New("Account is not scheduled for deletion")
+		}
+
+		if err := s.userRepo.SetDeletion(ctx, user.Username, nil, nil); err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, &models.SecurityEvent{
+			Username:  user.Username,
+			Type:      models.SecurityEventDeletionCancelled,
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account deletion cancelled")
+	return nil
+}
+
+func (s *accountDeletionService) PurgeDue(ctx context.Context) (int, error) {
+	now := s.clock.Now().UTC()
+	users, err := s.userRepo.FindDueForDeletion(ctx, now, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// A failed purge is retried at the next call, it does not hold up the
+	// other accounts
+	purged := 0
+	for i := range users {
+		err := s.purge(ctx, &users[i], now)
+		if err != nil {
+			if !errors.Is(err, errDeletionCancelled) {
+				logger.FromContext(ctx).WithError(err).WithField("purge_username", users[i].Username).Error("Failed to purge account")
+			}
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge deletes user and everything it owns in one transaction and records
+// the audit entry.
+func (s *accountDeletionService) purge(ctx context.Context, user *models.User, now time.Time) error {
+	deletion := &models.AccountDeletion{
+		ID:          uuid.New().String(),
+		RequestedAt: *user.DeletionDueAt,
+		PurgedAt:    now,
+	}
+	if user.DeletionRequestedAt != nil {
+		deletion.RequestedAt = *user.DeletionRequestedAt
+	}
+
+	var exports []string
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Contacts and addresses are kept from deletion by their foreign
+		// keys, so they go first
+		var err error
+		deletion.AddressCount, err = s.addressRepo.DeleteByUsername(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		deletion.ContactCount, err = s.contactRepo.DeleteByUsername(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		deletion.SessionCount, err = s.sessionRepo.DeleteOthers(ctx, user.Username, "")
+		if err != nil {
+			return err
+		}
+		// Export archives are files, they are removed once the rows are gone
+		exports, err = s.exports.DeleteByUsername(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		// The lockout counter is keyed by the username, not linked to the user
+		if err := s.attemptRepo.Delete(ctx, usernameSubject(user.Username)); err != nil {
+			return err
+		}
+
+		// The rest of the user's rows go with it. The deletion may have been
+		// cancelled since the user was found due, then nothing is deleted
+		deleted, err := s.userRepo.DeleteIfDue(ctx, user.Username, now)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return errDeletionCancelled
+		}
+
+		return s.deletionRepo.Create(ctx, deletion)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Archives left behind are still removed by the export purge once they
+	// expire, so a failure here does not undo the purge
+	if err := s.exports.RemoveArchives(ctx, exports); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("deletion_id", deletion.ID).Warn("Failed to remove data export archives")
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"deletion_id": deletion.ID,
+		"contacts":    deletion.ContactCount,
+		"addresses":   deletion.AddressCount,
+		"sessions":    deletion.SessionCount,
+		"exports":     len(exports),
+	}).Info("Account purged")
+	return nil
+}
@@ -1,34 +1,40 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"go-backend/internal/metrics"
 	"go-backend/internal/models"
 	"go-backend/internal/repository"
 	"go-backend/internal/utils"
 )
 
 type AddressService interface {
-	Create(contactID int, username string, req *models.AddressCreateRequest) (*models.AddressResponse, error)
-	GetByID(id int, contactID int, username string) (*models.AddressResponse, error)
-	Update(id int, contactID int, username string, req *models.AddressUpdateRequest) (*models.AddressResponse, error)
-	Delete(id int, contactID int, username string) error
-	GetByContactID(contactID int, username string) ([]models.AddressResponse, error)
+	Create(ctx context.Context, contactID int, username string, req *models.AddressCreateRequest) (*models.AddressResponse, error)
+	GetByID(ctx context.Context, id int, contactID int, username string) (*models.AddressResponse, error)
+	Update(ctx context.Context, id int, contactID int, username string, req *models.AddressUpdateRequest) (*models.AddressResponse, error)
+	Delete(ctx context.Context, id int, contactID int, username string) error
+	GetByContactID(ctx context.Context, contactID int, username string) ([]models.AddressResponse, error)
 }
 
 type addressService struct {
 	addressRepo repository.AddressRepository
 	contactRepo repository.ContactRepository
+	txManager   repository.TxManager
+	metrics     *metrics.Metrics
 }
 
-func NewAddressService(addressRepo repository.AddressRepository, contactRepo repository.ContactRepository) AddressService {
+func NewAddressService(addressRepo repository.AddressRepository, contactRepo repository.ContactRepository, txManager repository.TxManager, metrics *metrics.Metrics) AddressService {
 	return &addressService{
 		addressRepo: addressRepo,
 		contactRepo: contactRepo,
+		txManager:   txManager,
+		metrics:     metrics,
 	}
 }
 
-func (s *addressService) checkContactExists(contactID int, username string) error {
-	count, err := s.contactRepo.CountByID(contactID, username)
+func (s *addressService) checkContactExists(ctx context.Context, contactID int, username string) error {
+	count, err := s.contactRepo.CountByID(ctx, contactID, username)
 	if err != nil {
 		return err
 	}
@@ -38,11 +44,21 @@ func (s *addressService) checkContactExists(contactID int, username string) erro
 	return nil
 }
 
-func (s *addressService) Create(contactID int, username string, req *models.AddressCreateRequest) (*models.AddressResponse, error) {
-	if err := s.checkContactExists(contactID, username); err != nil {
-		return nil, err
+// lockContact is checkContactExists for use inside a transaction. It keeps the
+// contact locked so it cannot be deleted before the transaction ends, writes
+// rely on it alone instead of checking first outside the transaction.
+func (s *addressService) lockContact(ctx context.Context, contactID int, username string) error {
+	found, err := s.contactRepo.LockByID(ctx, contactID, username)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("contact is not found")
 	}
+	return nil
+}
 
+func (s *addressService) Create(ctx context.Context, contactID int, username string, req *models.AddressCreateRequest) (*models.AddressResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
@@ -56,10 +72,20 @@ func (s *addressService) Create(contactID int, username string, req *models.Addr
 		ContactID:  contactID,
 	}
 
-	createdAddress, err := s.addressRepo.Create(address)
+	var createdAddress *models.Address
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockContact(ctx, contactID, username); err != nil {
+			return err
+		}
+
+		var err error
+		createdAddress, err = s.addressRepo.Create(ctx, address)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.metrics.AddressCreated()
 
 	return &models.AddressResponse{
 		ID:         createdAddress.ID,
@@ -71,12 +97,12 @@ func (s *addressService) Create(contactID int, username string, req *models.Addr
 	}, nil
 }
 
-func (s *addressService) GetByID(id int, contactID int, username string) (*models.AddressResponse, error) {
-	if err := s.checkContactExists(contactID, username); err != nil {
+func (s *addressService) GetByID(ctx context.Context, id int, contactID int, username string) (*models.AddressResponse, error) {
+	if err := s.checkContactExists(ctx, contactID, username); err != nil {
 		return nil, err
 	}
 
-	address, err := s.addressRepo.FindByID(id, contactID)
+	address, err := s.addressRepo.FindByID(ctx, id, contactID)
 	if err != nil {
 		return nil, err
 	}
@@ -94,23 +120,11 @@ func (s *addressService) GetByID(id int, contactID int, username string) (*model
 	}, nil
 }
 
-func (s *addressService) Update(id int, contactID int, username string, req *models.AddressUpdateRequest) (*models.AddressResponse, error) {
-	if err := s.checkContactExists(contactID, username); err != nil {
-		return nil, err
-	}
-
+func (s *addressService) Update(ctx context.Context, id int, contactID int, username string, req *models.AddressUpdateRequest) (*models.AddressResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
 
-	count, err := s.addressRepo.CountByID(id, contactID)
-	if err != nil {
-		return nil, err
-	}
-	if count == 0 {
-		return nil, errors.New("address is not found")
-	}
-
 	address := &models.Address{
 		ID:         id,
 		Street:     req.Street,
@@ -121,7 +135,22 @@ func (s *addressService) Update(id int, contactID int, username string, req *mod
 		ContactID:  contactID,
 	}
 
-	if err := s.addressRepo.Update(address); err != nil {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockContact(ctx, contactID, username); err != nil {
+			return err
+		}
+
+		count, err := s.addressRepo.CountByID(ctx, id, contactID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("address is not found")
+		}
+
+		return s.addressRepo.Update(ctx, address)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -135,28 +164,30 @@ func (s *addressService) Update(id int, contactID int, username string, req *mod
 	}, nil
 }
 
-func (s *addressService) Delete(id int, contactID int, username string) error {
-	if err := s.checkContactExists(contactID, username); err != nil {
-		return err
-	}
-
-	count, err := s.addressRepo.CountByID(id, contactID)
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return errors.New("address is not found")
-	}
-
-	return s.addressRepo.Delete(id, contactID)
+func (s *addressService) Delete(ctx context.Context, id int, contactID int, username string) error {
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockContact(ctx, contactID, username); err != nil {
+			return err
+		}
+
+		count, err := s.addressRepo.CountByID(ctx, id, contactID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("address is not found")
+		}
+
+		return s.addressRepo.Delete(ctx, id, contactID)
+	})
 }
 
-func (s *addressService) GetByContactID(contactID int, username string) ([]models.AddressResponse, error) {
-	if err := s.checkContactExists(contactID, username); err != nil {
+func (s *addressService) GetByContactID(ctx context.Context, contactID int, username string) ([]models.AddressResponse, error) {
+	if err := s.checkContactExists(ctx, contactID, username); err != nil {
 		return nil, err
 	}
 
-	addresses, err := s.addressRepo.FindByContactID(contactID)
+	addresses, err := s.addressRepo.FindByContactID(ctx, contactID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/password"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"math"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUserNotFound is returned by the admin API for users that do not exist.
+var ErrUserNotFound = errors.New("User is not found")
+
+// ErrOutranked is returned by the admin API for changes to users, or
+// assignments of roles, with permissions the acting user does not have.
+var ErrOutranked = errors.New("User or role has permissions you do not have")
+
+// AdminService manages the accounts of other users. Every change is recorded
+// as a security event of the affected user. Admins cannot disable their own
+// account or change their own role, so they cannot lock themselves out, and
+// they can only change users whose role grants no permission they lack, so
+// they cannot take over or lock out an account that may do more.
+type AdminService interface {
+	SearchUsers(ctx context.Context, req *models.AdminUserSearchRequest) (*models.AdminUserSearchResponse, error)
+	GetUser(ctx context.Context, username string) (*models.AdminUserResponse, error)
+	// Disable rejects every further login and request of username and ends
+	// its sessions.
+	Disable(ctx context.Context, admin *models.AdminRequest, username string) error
+	Enable(ctx context.Context, admin *models.AdminRequest, username string) error
+	// Logout ends every session of username.
+	Logout(ctx context.Context, admin *models.AdminRequest, username string) (*models.RevokeSessionsResponse, error)
+	// SetPassword sets a new password under the password policy and ends
+	// every session of username.
+	SetPassword(ctx context.Context, admin *models.AdminRequest, username string, req *models.AdminPasswordRequest) (*models.RevokeSessionsResponse, error)
+	SetRole(ctx context.Context, admin *models.AdminRequest, username string, req *models.AdminRoleRequest) (*models.AdminUserResponse, error)
+}
+
+type adminService struct {
+	userRepo    repository.UserRepository
+	contactRepo repository.ContactRepository
+	roleRepo    repository.RoleRepository
+	sessionRepo repository.SessionRepository
+	txManager   repository.TxManager
+	passwords   PasswordService
+	events      SecurityEventService
+	hasher      *password.Hasher
+	clock       clock.Clock
+}
+
+func NewAdminService(userRepo repository.UserRepository, contactRepo repository.ContactRepository, roleRepo repository.RoleRepository, sessionRepo repository.SessionRepository, txManager repository.TxManager, passwords PasswordService, events SecurityEventService, hasher *password.Hasher, clk clock.Clock) AdminService {
+	return &adminService{
+		userRepo:    userRepo,
+		contactRepo: contactRepo,
+		roleRepo:    roleRepo,
+		sessionRepo: sessionRepo,
+		txManager:   txManager,
+		passwords:   passwords,
+		events:      events,
+		hasher:      hasher,
+		clock:       clk,
+	}
+}
+
+func (s *adminService) SearchUsers(ctx context.Context, req *models.AdminUserSearchRequest) (*models.AdminUserSearchResponse, error) {
+	// Set defaults
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Size <= 0 {
+		req.Size = 10
+	}
+	if req.Size > 100 {
+		req.Size = 100
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	users, totalItems, err := s.userRepo.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, len(users))
+	for i, user := range users {
+		usernames[i] = user.Username
+	}
+	counts, err := s.contactRepo.CountByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	userResponses := []models.AdminUserResponse{}
+	for i := range users {
+		userResponses = append(userResponses, *toAdminUserResponse(&users[i], counts[users[i].Username]))
+	}
+
+	totalPages := int(math.Ceil(float64(totalItems) / float64(req.Size)))
+
+	return &models.AdminUserSearchResponse{
+		Data: userResponses,
+		Paging: models.PagingResponse{
+			Page:      req.Page,
+			TotalPage: totalPages,
+			TotalItem: totalItems,
+		},
+	}, nil
+}
+
+func (s *adminService) GetUser(ctx context.Context, username string) (*models.AdminUserResponse, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return s.userResponse(ctx, user)
+}
+
+func (s *adminService) Disable(ctx context.Context, admin *models.AdminRequest, username string) error {
+	if strings.EqualFold(admin.Username, username) {
+		return errors.New("You cannot disable your own account")
+	}
+
+	var revoked int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.findUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOutranks(ctx, admin, user.Role); err != nil {
+			return err
+		}
+		if user.DisabledAt != nil {
+			return nil
+		}
+
+		now := s.clock.Now().UTC()
+		if err := s.userRepo.SetDisabled(ctx, user.Username, &now); err != nil {
+			return err
+		}
+		// API keys stay, they are rejected while the user is disabled
+		revoked, err = s.sessionRepo.DeleteOthers(ctx, user.Username, "")
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, admin, user.Username, models.SecurityEventAccountDisabled)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"target_username": username, "revoked": revoked}).Info("User disabled")
+	return nil
+}
+
+func (s *adminService) Enable(ctx context.Context, admin *models.AdminRequest, username string) error {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.findUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOutranks(ctx, admin, user.Role); err != nil {
+			return err
+		}
+		if user.DisabledAt == nil {
+			return nil
+		}
+
+		if err := s.userRepo.SetDisabled(ctx, user.Username, nil); err != nil {
+			return err
+		}
+
+		return s.record(ctx, admin, user.Username, models.SecurityEventAccountEnabled)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithField("target_username", username).Info("User enabled")
+	return nil
+}
+
+func (s *adminService) Logout(ctx context.Context, admin *models.AdminRequest, username string) (*models.RevokeSessionsResponse, error) {
+	var revoked int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.findUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOutranks(ctx, admin, user.Role); err != nil {
+			return err
+		}
+
+		revoked, err = s.sessionRepo.DeleteOthers(ctx, user.Username, "")
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, admin, user.Username, models.SecurityEventSessionsRevoked)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"target_username": username, "revoked": revoked}).Info("User logged out")
+	return &models.RevokeSessionsResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+func (s *adminService) SetPassword(ctx context.Context, admin *models.AdminRequest, username string, req *models.AdminPasswordRequest) (*models.RevokeSessionsResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOutranks(ctx, admin, user.Role); err != nil {
+		return nil, err
+	}
+	if err := s.passwords.Validate(ctx, user.Username, req.NewPassword); err != nil {
+		return nil, err
+	}
+	hash, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	var revoked int
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdatePassword(ctx, user.Username, hash); err != nil {
+			return err
+		}
+		if err := s.passwords.Remember(ctx, user.Username, hash); err != nil {
+			return err
+		}
+
+		var err error
+		revoked, err = s.sessionRepo.DeleteOthers(ctx, user.Username, "")
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, admin, user.Username, models.SecurityEventPasswordReset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"target_username": username, "revoked": revoked}).Info("User password set")
+	return &models.RevokeSessionsResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+func (s *adminService) SetRole(ctx context.Context, admin *models.AdminRequest, username string, req *models.AdminRoleRequest) (*models.AdminUserResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(admin.Username, username) {
+		return nil, errors.New("You cannot change your own role")
+	}
+
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.findUser(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		role, err := s.roleRepo.FindByName(ctx, req.Role)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+		if user.Role == role.Name {
+			return nil
+		}
+		// Neither the current nor the new role may grant more than the
+		// admin has, or users could be promoted past the one promoting them
+		if err := s.checkOutranks(ctx, admin, user.Role); err != nil {
+			return err
+		}
+		if err := s.checkOutranks(ctx, admin, role.Name); err != nil {
+			return err
+		}
+
+		if err := s.userRepo.UpdateRole(ctx, user.Username, role.Name); err != nil {
+			return err
+		}
+		user.Role = role.Name
+
+		return s.record(ctx, admin, user.Username, models.SecurityEventRoleChanged)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"target_username": username, "role": user.Role}).Info("User role changed")
+	return s.userResponse(ctx, user)
+}
+
+// findUser returns username or ErrUserNotFound.
+func (s *adminService) findUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// checkOutranks returns ErrOutranked unless admin has every permission the
+// role with roleName grants. Roles that no longer exist grant none.
+func (s *adminService) checkOutranks(ctx context.Context, admin *models.AdminRequest, roleName string) error {
+	role, err := s.roleRepo.FindByName(ctx, roleName)
+	if err != nil || role == nil {
+		return err
+	}
+
+	held := make(map[string]bool, len(admin.Permissions))
+	for _, permission := range admin.Permissions {
+		held[permission] = true
+	}
+	for _, permission := range rolePermissions(role) {
+		if !held[permission] {
+			return ErrOutranked
+		}
+	}
+	return nil
+}
+
+func (s *adminService) userResponse(ctx context.Context, user *models.User) (*models.AdminUserResponse, error) {
+	counts, err := s.contactRepo.CountByUsernames(ctx, []string{user.Username})
+	if err != nil {
+		return nil, err
+	}
+	return toAdminUserResponse(user, counts[user.Username]), nil
+}
+
+// record adds a security event of type to the account of username. The
+// address and user agent are those of the admin.
+func (s *adminService) record(ctx context.Context, admin *models.AdminRequest, username string, eventType string) error {
+	return s.events.Record(ctx, &models.SecurityEvent{
+		Username:  username,
+		Type:      eventType,
+		IPAddress: truncate(admin.IPAddress, 45),
+		UserAgent: truncate(admin.UserAgent, 255),
+	})
+}
+
+func toAdminUserResponse(user *models.User, contactCount int) *models.AdminUserResponse {
+	return &models.AdminUserResponse{
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Role:          user.Role,
+		DisabledAt:    user.DisabledAt,
+		DeletionDueAt: user.DeletionDueAt,
+		ContactCount:  contactCount,
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from session and
+// access tokens in the Authorization header.
+const APIKeyPrefix = "cak_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear text, so the user
+// can recognize it in the list of keys.
+const apiKeyDisplayLength = len(APIKeyPrefix) + 6
+
+type APIKeyService interface {
+	// Create mints a key with a subset of the scopes of principal. The key is
+	// only returned by this call.
+	Create(ctx context.Context, principal *auth.Principal, req *models.APIKeyCreateRequest) (*models.APIKeyResponse, error)
+	List(ctx context.Context, username string) ([]models.APIKeyResponse, error)
+	Revoke(ctx context.Context, username string, id string) error
+	// Authenticate resolves an API key sent from ip to the principal it
+	// belongs to. Unknown, expired and disallowed keys are reported as
+	// ErrInvalidSession.
+	Authenticate(ctx context.Context, key string, ip string) (*auth.Principal, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo    repository.APIKeyRepository
+	clock         clock.Clock
+	renewInterval time.Duration
+}
+
+// NewAPIKeyService returns an APIKeyService that records the last use of a
+// key at most once per renewInterval.
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, clk clock.Clock, renewInterval time.Duration) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:    apiKeyRepo,
+		clock:         clk,
+		renewInterval: renewInterval,
+	}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, principal *auth.Principal, req *models.APIKeyCreateRequest) (*models.APIKeyResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	var scopes []string
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			return nil, errors.New("Scope " + scope + " is not valid")
+		}
+		// A key never grants more than the credential that created it
+		if !principal.HasScope(scope) {
+			return nil, errors.New("Scope " + scope + " is not granted")
+		}
+		if !contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	allowedIPs := make([]string, 0, len(req.AllowedIPs))
+	for _, entry := range req.AllowedIPs {
+		prefix, err := parseIPPrefix(entry)
+		if err != nil {
+			return nil, errors.New("AllowedIPs entry " + entry + " is not valid")
+		}
+		allowedIPs = append(allowedIPs, prefix.String())
+	}
+
+	now := s.clock.Now().UTC()
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, errors.New("ExpiresAt must be in the future")
+		}
+		t := req.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	key := APIKeyPrefix + token
+
+	apiKey := &models.APIKey{
+		ID:         uuid.New().String(),
+		Username:   principal.Username,
+		Name:       req.Name,
+		Prefix:     key[:apiKeyDisplayLength],
+		KeyHash:    utils.HashToken(key),
+		Scopes:     scopes,
+		AllowedIPs: allowedIPs,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).WithFields(logrus.Fields{"api_key_id": apiKey.ID, "scopes": strings.Join(scopes, " ")}).Info("API key created")
+
+	res := toAPIKeyResponse(apiKey)
+	res.Key = key
+	return &res, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, username string) ([]models.APIKeyResponse, error) {
+	keys, err := s.apiKeyRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, toAPIKeyResponse(&keys[i]))
+	}
+
+	return responses, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, username string, id string) error {
+	key, err := s.apiKeyRepo.FindByID(ctx, id, username)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return errors.New("api key is not found")
+	}
+
+	if err := s.apiKeyRepo.Delete(ctx, id, username); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithField("api_key_id", id).Info("API key revoked")
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, key string, ip string) (*auth.Principal, error) {
+	apiKey, err := s.apiKeyRepo.FindByHash(ctx, utils.HashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil {
+		return nil, ErrInvalidSession
+	}
+
+	now := s.clock.Now().UTC()
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now) {
+		return nil, ErrInvalidSession
+	}
+	if !ipAllowed(apiKey.AllowedIPs, ip) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{"api_key_id": apiKey.ID, "ip": ip}).Warn("API key used from an address that is not allowed")
+		return nil, ErrInvalidSession
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= s.renewInterval {
+		if err := s.apiKeyRepo.Touch(ctx, apiKey.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &auth.Principal{
+		Username: apiKey.Username,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+		Method:   auth.MethodAPIKey,
+	}, nil
+}
+
+func toAPIKeyResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedIPs,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+	}
+}
+
+// parseIPPrefix parses an address or CIDR range. A single address becomes a
+// range of one address.
+func parseIPPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ipAllowed reports whether ip is in one of allowed, an empty list allows
+// every address.
+func ipAllowed(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range allowed {
+		prefix, err := netip.ParsePrefix(entry)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"go-backend/internal/logger"
+	"go-backend/internal/metrics"
 	"go-backend/internal/models"
 	"go-backend/internal/repository"
 	"go-backend/internal/utils"
@@ -9,24 +12,30 @@ import (
 )
 
 type ContactService interface {
-	Create(username string, req *models.ContactCreateRequest) (*models.ContactResponse, error)
-	GetByID(id int, username string) (*models.ContactResponse, error)
-	Update(id int, username string, req *models.ContactUpdateRequest) (*models.ContactResponse, error)
-	Delete(id int, username string) error
-	Search(username string, req *models.ContactSearchRequest) (*models.ContactSearchResponse, error)
+	Create(ctx context.Context, username string, req *models.ContactCreateRequest) (*models.ContactResponse, error)
+	GetByID(ctx context.Context, id int, username string) (*models.ContactResponse, error)
+	Update(ctx context.Context, id int, username string, req *models.ContactUpdateRequest) (*models.ContactResponse, error)
+	Delete(ctx context.Context, id int, username string) error
+	Search(ctx context.Context, username string, req *models.ContactSearchRequest) (*models.ContactSearchResponse, error)
 }
 
 type contactService struct {
 	contactRepo repository.ContactRepository
+	addressRepo repository.AddressRepository
+	txManager   repository.TxManager
+	metrics     *metrics.Metrics
 }
 
-func NewContactService(contactRepo repository.ContactRepository) ContactService {
+func NewContactService(contactRepo repository.ContactRepository, addressRepo repository.AddressRepository, txManager repository.TxManager, metrics *metrics.Metrics) ContactService {
 	return &contactService{
 		contactRepo: contactRepo,
+		addressRepo: addressRepo,
+		txManager:   txManager,
+		metrics:     metrics,
 	}
 }
 
-func (s *contactService) Create(username string, req *models.ContactCreateRequest) (*models.ContactResponse, error) {
+func (s *contactService) Create(ctx context.Context, username string, req *models.ContactCreateRequest) (*models.ContactResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
@@ -39,10 +48,11 @@ func (s *contactService) Create(username string, req *models.ContactCreateReques
 		Username:  username,
 	}
 
-	createdContact, err := s.contactRepo.Create(contact)
+	createdContact, err := s.contactRepo.Create(ctx, contact)
 	if err != nil {
 		return nil, err
 	}
+	s.metrics.ContactCreated()
 
 	return &models.ContactResponse{
 		ID:        createdContact.ID,
@@ -53,8 +63,8 @@ func (s *contactService) Create(username string, req *models.ContactCreateReques
 	}, nil
 }
 
-func (s *contactService) GetByID(id int, username string) (*models.ContactResponse, error) {
-	contact, err := s.contactRepo.FindByID(id, username)
+func (s *contactService) GetByID(ctx context.Context, id int, username string) (*models.ContactResponse, error) {
+	contact, err := s.contactRepo.FindByID(ctx, id, username)
 	if err != nil {
 		return nil, err
 	}
@@ -71,12 +81,12 @@ func (s *contactService) GetByID(id int, username string) (*models.ContactRespon
 	}, nil
 }
 
-func (s *contactService) Update(id int, username string, req *models.ContactUpdateRequest) (*models.ContactResponse, error) {
+func (s *contactService) Update(ctx context.Context, id int, username string, req *models.ContactUpdateRequest) (*models.ContactResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
 
-	count, err := s.contactRepo.CountByID(id, username)
+	count, err := s.contactRepo.CountByID(ctx, id, username)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +103,7 @@ func (s *contactService) Update(id int, username string, req *models.ContactUpda
 		Username:  username,
 	}
 
-	if err := s.contactRepo.Update(contact); err != nil {
+	if err := s.contactRepo.Update(ctx, contact); err != nil {
 		return nil, err
 	}
 
@@ -106,19 +116,31 @@ func (s *contactService) Update(id int, username string, req *models.ContactUpda
 	}, nil
 }
 
-func (s *contactService) Delete(id int, username string) error {
-	count, err := s.contactRepo.CountByID(id, username)
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return errors.New("contact is not found")
-	}
-
-	return s.contactRepo.Delete(id, username)
+// Delete removes the contact together with its addresses.
+func (s *contactService) Delete(ctx context.Context, id int, username string) error {
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		found, err := s.contactRepo.LockByID(ctx, id, username)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errors.New("contact is not found")
+		}
+
+		if err := s.addressRepo.DeleteByContactID(ctx, id); err != nil {
+			return err
+		}
+
+		if err := s.contactRepo.Delete(ctx, id, username); err != nil {
+			return err
+		}
+
+		logger.FromContext(ctx).WithField("contact_id", id).Info("Contact deleted with its addresses")
+		return nil
+	})
 }
 
-func (s *contactService) Search(username string, req *models.ContactSearchRequest) (*models.ContactSearchResponse, error) {
+func (s *contactService) Search(ctx context.Context, username string, req *models.ContactSearchRequest) (*models.ContactSearchResponse, error) {
 	// Set defaults
 	if req.Page <= 0 {
 		req.Page = 1
@@ -134,7 +156,7 @@ func (s *contactService) Search(username string, req *models.ContactSearchReques
 		return nil, err
 	}
 
-	contacts, totalItems, err := s.contactRepo.Search(req, username)
+	contacts, totalItems, err := s.contactRepo.Search(ctx, req, username)
 	if err != nil {
 		return nil, err
 	}
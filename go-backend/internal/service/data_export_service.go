@@ -0,0 +1,615 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// exportBuildTimeout bounds the building of an archive, an export that
+	// is pending for longer is considered failed.
+	exportBuildTimeout = 10 * time.Minute
+	// exportPageSize is how many contacts are read at once.
+	exportPageSize = 100
+	// exportEventLimit is how many of the latest security events are
+	// exported.
+	exportEventLimit = 10000
+)
+
+var (
+	ErrInvalidExportLink = errors.New("Download link is invalid or expired")
+	ErrExportNotFound    = errors.New("Export is not found")
+)
+
+// ExportPolicy controls where archives are kept and how they are downloaded.
+type ExportPolicy struct {
+	Dir string
+	// TTL is how long an archive can be downloaded once it is ready.
+	TTL time.Duration
+	// SigningSecret signs the download links.
+	SigningSecret []byte
+	// BaseURL is the public address of the API, links are relative when it
+	// is empty.
+	BaseURL string
+}
+
+// DataExportArchive is an open archive, the caller closes it.
+type DataExportArchive struct {
+	*os.File
+	Name    string
+	ModTime time.Time
+}
+
+// DataExportService exports everything stored about a user as a ZIP archive
+// of JSON files and a vCard file of the contacts. Archives are built in the
+// background and downloaded through signed links that expire with them.
+type DataExportService interface {
+	// Request returns the latest export of username. A new export is
+	// started unless one is being built or ready to download.
+	Request(ctx context.Context, username string) (*models.DataExportResponse, error)
+	// Open returns the archive of a download link after checking its
+	// signature and expiry.
+	Open(ctx context.Context, id string, expires string, signature string) (*DataExportArchive, error)
+	// PurgeExpired deletes the expired archives and returns how many exports
+	// it deleted. Exports whose build was interrupted, for example by a
+	// crash, are marked as failed.
+	PurgeExpired(ctx context.Context) (int, error)
+	// DeleteByUsername deletes every export of username and returns their
+	// IDs. The archives are left for RemoveArchives, to be called once the
+	// transaction ctx may carry has committed.
+	DeleteByUsername(ctx context.Context, username string) ([]string, error)
+	// RemoveArchives removes the archives of the exports with ids.
+	RemoveArchives(ctx context.Context, ids []string) error
+	// Shutdown waits for the archives being built. Builds still running when
+	// ctx is done are cancelled and marked as failed before it returns.
+	Shutdown(ctx context.Context) error
+}
+
+type dataExportService struct {
+	exportRepo   repository.DataExportRepository
+	userRepo     repository.UserRepository
+	contactRepo  repository.ContactRepository
+	addressRepo  repository.AddressRepository
+	sessionRepo  repository.SessionRepository
+	apiKeyRepo   repository.APIKeyRepository
+	totpRepo     repository.TOTPRepository
+	identityRepo repository.UserIdentityRepository
+	eventRepo    repository.SecurityEventRepository
+	txManager    repository.TxManager
+	clock        clock.Clock
+	policy       ExportPolicy
+	// builds tracks the archives being built, stopping cancels them
+	builds     sync.WaitGroup
+	stopping   context.Context
+	stopBuilds context.CancelFunc
+}
+
+func NewDataExportService(exportRepo repository.DataExportRepository, userRepo repository.UserRepository, contactRepo repository.ContactRepository, addressRepo repository.AddressRepository, sessionRepo repository.SessionRepository, apiKeyRepo repository.APIKeyRepository, totpRepo repository.TOTPRepository, identityRepo repository.UserIdentityRepository, eventRepo repository.SecurityEventRepository, txManager repository.TxManager, clk clock.Clock, policy ExportPolicy) (DataExportService, error) {
+	if err := os.MkdirAll(policy.Dir, 0o750); err != nil {
+		return nil, err
+	}
+	stopping, stopBuilds := context.WithCancel(context.Background())
+	return &dataExportService{
+		exportRepo:   exportRepo,
+		userRepo:     userRepo,
+		contactRepo:  contactRepo,
+		addressRepo:  addressRepo,
+		sessionRepo:  sessionRepo,
+		apiKeyRepo:   apiKeyRepo,
+		totpRepo:     totpRepo,
+		identityRepo: identityRepo,
+		eventRepo:    eventRepo,
+		txManager:    txManager,
+		clock:        clk,
+		policy:       policy,
+		stopping:     stopping,
+		stopBuilds:   stopBuilds,
+	}, nil
+}
+
+func (s *dataExportService) Request(ctx context.Context, username string) (*models.DataExportResponse, error) {
+	now := s.clock.Now().UTC()
+	var export *models.DataExport
+	started := false
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		latest, err := s.exportRepo.FindLatest(ctx, username)
+		if err != nil {
+			return err
+		}
+		if latest != nil && s.usable(latest, now) {
+			export = latest
+			return nil
+		}
+
+		export = &models.DataExport{
+			ID:        uuid.New().String(),
+			Username:  username,
+			Status:    models.DataExportPending,
+			CreatedAt: now,
+		}
+		started = true
+		return s.exportRepo.Create(ctx, export)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if started {
+		// The archive is built in the background, the user polls until it
+		// is ready
+		log := logger.FromContext(ctx).WithField("export_id", export.ID)
+		building := *export
+		s.builds.Add(1)
+		go func() {
+			defer s.builds.Done()
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), exportBuildTimeout)
+			defer cancel()
+			defer context.AfterFunc(s.stopping, cancel)()
+
+			if err := s.build(ctx, &building); err != nil {
+				log.WithError(err).Error("Failed to build data export")
+				s.fail(context.WithoutCancel(ctx), &building, log)
+				return
+			}
+			log.WithField("size", building.Size).Info("Data export ready")
+		}()
+		logger.FromContext(ctx).WithField("export_id", export.ID).Info("Data export started")
+	}
+
+	return s.toResponse(export), nil
+}
+
+func (s *dataExportService) Open(ctx context.Context, id string, expires string, signature string) (*DataExportArchive, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidExportLink
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expiresAt))) || s.clock.Now().Unix() >= expiresAt {
+		return nil, ErrInvalidExportLink
+	}
+
+	export, err := s.exportRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.Status != models.DataExportReady {
+		return nil, ErrExportNotFound
+	}
+
+	file, err := os.Open(s.path(export.ID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	return &DataExportArchive{
+		File:    file,
+		Name:    "export-" + export.CreatedAt.Format("20060102T150405") + ".zip",
+		ModTime: *export.CompletedAt,
+	}, nil
+}
+
+func (s *dataExportService) PurgeExpired(ctx context.Context) (int, error) {
+	// Builds are cancelled after exportBuildTimeout, whichever instance runs
+	// them, so an export pending for longer is no longer being built
+	now := s.clock.Now().UTC()
+	failed, err := s.exportRepo.FailPending(ctx, now.Add(-exportBuildTimeout), now)
+	if err != nil {
+		return 0, err
+	}
+	if failed > 0 {
+		logger.FromContext(ctx).WithField("failed", failed).Warn("Interrupted data exports marked as failed")
+	}
+
+	// Every export is ready or failed within exportBuildTimeout and expires
+	// TTL later, so anything created before that is gone
+	cutoff := now.Add(-exportBuildTimeout - s.policy.TTL)
+	deleted, err := s.exportRepo.DeleteCreatedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	// Archives are matched by age rather than by row, which also removes
+	// those of accounts that were deleted in the meantime
+	entries, err := os.ReadDir(s.policy.Dir)
+	if err != nil {
+		return deleted, err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.policy.Dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.FromContext(ctx).WithError(err).WithField("file", entry.Name()).Warn("Failed to delete expired archive")
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *dataExportService) DeleteByUsername(ctx context.Context, username string) ([]string, error) {
+	var ids []string
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		exports, err := s.exportRepo.FindByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		for _, export := range exports {
+			ids = append(ids, export.ID)
+		}
+		_, err = s.exportRepo.DeleteByUsername(ctx, username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *dataExportService) RemoveArchives(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		for _, path := range []string{s.path(id), s.path(id) + ".tmp"} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *dataExportService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.builds.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// Cancelled builds still mark their export as failed, which is
+		// quick, so they are waited for
+		s.stopBuilds()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// usable reports whether export is still being built or can be downloaded.
+func (s *dataExportService) usable(export *models.DataExport, now time.Time) bool {
+	switch export.Status {
+	case models.DataExportPending:
+		return now.Before(export.CreatedAt.Add(exportBuildTimeout))
+	case models.DataExportReady:
+		if export.ExpiresAt == nil || !now.Before(*export.ExpiresAt) {
+			return false
+		}
+		// An archive that is gone cannot be downloaded anymore
+		_, err := os.Stat(s.path(export.ID))
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// build writes the archive of export and marks it ready.
+func (s *dataExportService) build(ctx context.Context, export *models.DataExport) error {
+	// The archive is written under a temporary name, so a partial archive
+	// is never served
+	path := s.path(export.ID)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	// Everything is read from one snapshot, so changes the user makes
+	// during the build do not leave the archive half old and half new
+	zw := zip.NewWriter(file)
+	err = s.txManager.WithinReadTx(ctx, func(ctx context.Context) error {
+		return s.write(ctx, zw, export.Username)
+	})
+	if err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	now := s.clock.Now().UTC()
+	expiresAt := now.Add(s.policy.TTL)
+	export.Status = models.DataExportReady
+	export.Size = info.Size()
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return s.exportRepo.Update(ctx, export)
+}
+
+// fail marks export as failed, so the next request starts a new one.
+func (s *dataExportService) fail(ctx context.Context, export *models.DataExport, log *logrus.Entry) {
+	now := s.clock.Now().UTC()
+	export.Status = models.DataExportFailed
+	export.CompletedAt = &now
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		log.WithError(err).Warn("Failed to mark data export as failed")
+	}
+}
+
+// write adds the files of the archive of username to zw.
+func (s *dataExportService) write(ctx context.Context, zw *zip.Writer, username string) error {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user is not found")
+	}
+	credential, err := s.totpRepo.FindByUsername(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+	profile := models.ExportProfile{
+		Username:            user.Username,
+		Name:                user.Name,
+		Email:               user.Email,
+		EmailVerifiedAt:     user.EmailVerifiedAt,
+		Role:                user.Role,
+		TwoFactorEnabled:    credential != nil && credential.ConfirmedAt != nil,
+		DisabledAt:          user.DisabledAt,
+		DeletionRequestedAt: user.DeletionRequestedAt,
+		DeletionDueAt:       user.DeletionDueAt,
+	}
+	now := s.clock.Now().UTC()
+	if err := writeJSON(zw, "profile.json", now, profile); err != nil {
+		return err
+	}
+
+	// Sessions that went idle but were not cleaned up yet are included
+	sessions, err := s.sessionRepo.FindByUsername(ctx, user.Username, now, time.Time{})
+	if err != nil {
+		return err
+	}
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+	if err := writeJSON(zw, "sessions.json", now, sessions); err != nil {
+		return err
+	}
+
+	events, err := s.eventRepo.FindByUsername(ctx, user.Username, exportEventLimit)
+	if err != nil {
+		return err
+	}
+	if events == nil {
+		events = []models.SecurityEvent{}
+	}
+	if err := writeJSON(zw, "login_history.json", now, events); err != nil {
+		return err
+	}
+
+	keys, err := s.apiKeyRepo.FindByUsername(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+	keyResponses := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		keyResponses = append(keyResponses, toAPIKeyResponse(&keys[i]))
+	}
+	if err := writeJSON(zw, "api_keys.json", now, keyResponses); err != nil {
+		return err
+	}
+
+	identities, err := s.identityRepo.FindByUsername(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+	identityResponses := make([]models.ExportIdentity, 0, len(identities))
+	for _, identity := range identities {
+		identityResponses = append(identityResponses, models.ExportIdentity{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, "identities.json", now, identityResponses); err != nil {
+		return err
+	}
+
+	contacts, err := s.contacts(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "contacts.json", now, contacts); err != nil {
+		return err
+	}
+	var vcards strings.Builder
+	for i := range contacts {
+		writeVCard(&vcards, &contacts[i])
+	}
+	return writeFile(zw, "contacts.vcf", now, []byte(vcards.String()))
+}
+
+// contacts returns every contact of username with its addresses, page by
+// page in the order of their ids.
+func (s *dataExportService) contacts(ctx context.Context, username string) ([]models.ExportContact, error) {
+	result := []models.ExportContact{}
+	afterID := 0
+	for {
+		contacts, err := s.contactRepo.FindPageByUsername(ctx, username, afterID, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, contact := range contacts {
+			addresses, err := s.addressRepo.FindByContactID(ctx, contact.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			exported := models.ExportContact{
+				ContactResponse: models.ContactResponse{
+					ID:        contact.ID,
+					FirstName: contact.FirstName,
+					LastName:  contact.LastName,
+					Email:     contact.Email,
+					Phone:     contact.Phone,
+				},
+				Addresses: make([]models.AddressResponse, 0, len(addresses)),
+			}
+			for _, address := range addresses {
+				exported.Addresses = append(exported.Addresses, models.AddressResponse{
+					ID:         address.ID,
+					Street:     address.Street,
+					City:       address.City,
+					Province:   address.Province,
+					Country:    address.Country,
+					PostalCode: address.PostalCode,
+				})
+			}
+			result = append(result, exported)
+		}
+
+		if len(contacts) < exportPageSize {
+			return result, nil
+		}
+		afterID = contacts[len(contacts)-1].ID
+	}
+}
+
+func (s *dataExportService) toResponse(export *models.DataExport) *models.DataExportResponse {
+	res := &models.DataExportResponse{
+		ID:          export.ID,
+		Status:      export.Status,
+		Size:        export.Size,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+	if export.Status == models.DataExportReady && export.ExpiresAt != nil {
+		expires := export.ExpiresAt.Unix()
+		res.DownloadURL = strings.TrimRight(s.policy.BaseURL, "/") + "/api/exports/" + export.ID +
+			"?expires=" + strconv.FormatInt(expires, 10) + "&signature=" + url.QueryEscape(s.sign(export.ID, expires))
+	}
+	return res
+}
+
+// sign returns the signature of a download link of export id that expires at
+// the unix time expires.
+func (s *dataExportService) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, s.policy.SigningSecret)
+	mac.Write([]byte(id + ":" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *dataExportService) path(id string) string {
+	return filepath.Join(s.policy.Dir, id+".zip")
+}
+
+func writeJSON(zw *zip.Writer, name string, modified time.Time, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(zw, name, modified, append(data, '\n'))
+}
+
+func writeFile(zw *zip.Writer, name string, modified time.Time, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeVCard appends contact as a vCard 3.0 entry to b.
+func writeVCard(b *strings.Builder, contact *models.ExportContact) {
+	lastName := ""
+	if contact.LastName != nil {
+		lastName = *contact.LastName
+	}
+	fullName := strings.TrimSpace(contact.FirstName + " " + lastName)
+
+	writeVCardLine(b, "BEGIN:VCARD")
+	writeVCardLine(b, "VERSION:3.0")
+	writeVCardLine(b, "N:"+vcardEscape(lastName)+";"+vcardEscape(contact.FirstName)+";;;")
+	writeVCardLine(b, "FN:"+vcardEscape(fullName))
+	if contact.Email != nil {
+		writeVCardLine(b, "EMAIL;TYPE=INTERNET:"+vcardEscape(*contact.Email))
+	}
+	if contact.Phone != nil {
+		writeVCardLine(b, "TEL:"+vcardEscape(*contact.Phone))
+	}
+	for _, address := range contact.Addresses {
+		writeVCardLine(b, "ADR:;;"+vcardEscape(stringValue(address.Street))+";"+vcardEscape(stringValue(address.City))+";"+
+			vcardEscape(stringValue(address.Province))+";"+vcardEscape(address.PostalCode)+";"+vcardEscape(address.Country))
+	}
+	writeVCardLine(b, "END:VCARD")
+}
+
+// writeVCardLine writes line folded after 75 octets, as RFC 2425 asks.
+func writeVCardLine(b *strings.Builder, line string) {
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-backend/internal/auth"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/mail"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalidEmailToken is returned for unknown, used and expired tokens of
+// password reset and verification mails.
+var ErrInvalidEmailToken = errors.New("Invalid or expired token")
+
+// EmailPolicy controls the tokens that are mailed to users.
+type EmailPolicy struct {
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+	// ResendInterval is how long a user has to wait for another mail of the
+	// same kind.
+	ResendInterval time.Duration
+	// LinkBaseURL is the address of the frontend, mails link to its
+	// /verify-email and /reset-password pages.
+	LinkBaseURL string
+}
+
+// link returns the address of page with token as query parameter.
+func (p EmailPolicy) link(page string, token string) string {
+	return strings.TrimRight(p.LinkBaseURL, "/") + page + "?token=" + url.QueryEscape(token)
+}
+
+// emailTokens issues and redeems the single-use tokens mailed to users. Only
+// the hash of a token is stored.
+type emailTokens struct {
+	repo   repository.EmailTokenRepository
+	clock  clock.Clock
+	policy EmailPolicy
+}
+
+// issue replaces the tokens of user for purpose with a new one, which is
+// returned. It returns an empty token when the latest one was issued less
+// than ResendInterval ago.
+func (t emailTokens) issue(ctx context.Context, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	now := t.clock.Now().UTC()
+	latest, err := t.repo.FindLatest(ctx, user.Username, purpose)
+	if err != nil {
+		return "", err
+	}
+	if latest != nil && now.Sub(latest.CreatedAt) < t.policy.ResendInterval {
+		return "", nil
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Only the token of the latest mail is valid
+	if err := t.repo.DeleteByUsername(ctx, user.Username, purpose); err != nil {
+		return "", err
+	}
+	err = t.repo.Create(ctx, &models.EmailToken{
+		TokenHash: utils.HashToken(token),
+		Username:  user.Username,
+		Purpose:   purpose,
+		Email:     *user.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// redeem returns the user token was issued to and deletes every token of the
+// user for purpose. It has to run in a transaction, which keeps the token
+// locked so it is redeemed once. Tokens mailed to an address the user no
+// longer has are rejected.
+func (t emailTokens) redeem(ctx context.Context, userRepo repository.UserRepository, token string, purpose string) (*models.User, error) {
+	stored, err := t.repo.FindByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Purpose != purpose || !stored.ExpiresAt.After(t.clock.Now().UTC()) {
+		return nil, ErrInvalidEmailToken
+	}
+
+	user, err := userRepo.FindByUsername(ctx, stored.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Email == nil || !strings.EqualFold(*user.Email, stored.Email) {
+		return nil, ErrInvalidEmailToken
+	}
+
+	if err := t.repo.DeleteByUsername(ctx, user.Username, purpose); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// EmailVerificationService confirms that users control the email address of
+// their account. Sessions of unverified accounts can be limited to managing
+// the account, see SessionPolicy.RequireVerifiedEmail.
+type EmailVerificationService interface {
+	// Send mails a verification link to the address of username.
+	Send(ctx context.Context, username string) error
+	// Verify marks the address a token was mailed to as verified and lifts
+	// the limits of the sessions of its user.
+	Verify(ctx context.Context, req *models.EmailVerifyRequest) error
+}
+
+type emailVerificationService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	txManager   repository.TxManager
+	events      SecurityEventService
+	mailer      mail.Mailer
+	tokens      emailTokens
+}
+
+func NewEmailVerificationService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.EmailTokenRepository, txManager repository.TxManager, events SecurityEventService, mailer mail.Mailer, clk clock.Clock, policy EmailPolicy) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		txManager:   txManager,
+		events:      events,
+		mailer:      mailer,
+		tokens: emailTokens{
+			repo:   tokenRepo,
+			clock:  clk,
+			policy: policy,
+		},
+	}
+}
+
+func (s *emailVerificationService) Send(ctx context.Context, username string) error {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user is not found")
+	}
+	if user.Email == nil {
+		return errors.New("Account has no email address")
+	}
+	if user.EmailVerifiedAt != nil {
+		return errors.New("Email address is already verified")
+	}
+
+	token, err := s.tokens.issue(ctx, user, models.EmailTokenVerify, s.tokens.policy.VerificationTTL)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("A verification mail was sent recently, try again later")
+	}
+
+	err = s.mailer.Send(ctx, &mail.Message{
+		To:      *user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nplease confirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not create an account, you can ignore this mail.\n",
+			user.Name, s.tokens.policy.link("/verify-email", token), formatDuration(s.tokens.policy.VerificationTTL)),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Verification mail sent")
+	return nil
+}
+
+func (s *emailVerificationService) Verify(ctx context.Context, req *models.EmailVerifyRequest) error {
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.tokens.redeem(ctx, s.userRepo, req.Token, models.EmailTokenVerify)
+		if err != nil {
+			return err
+		}
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+
+		now := s.tokens.clock.Now().UTC()
+		if err := s.userRepo.MarkEmailVerified(ctx, user.Username, now); err != nil {
+			return err
+		}
+		// Sessions started before the verification get the full scopes,
+		// access tokens pick them up at their next refresh
+		user.EmailVerifiedAt = &now
+		if err := s.sessionRepo.UpdateScopes(ctx, user.Username, auth.AllScopes()); err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, &models.SecurityEvent{
+			Username: user.Username,
+			Type:     models.SecurityEventEmailVerified,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithField("verified_username", user.Username).Info("Email address verified")
+	return nil
+}
+
+// formatDuration formats d in whole hours or minutes for mails.
+func formatDuration(d time.Duration) string {
+	n, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int(d/time.Hour), "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LoginLockedError is returned for logins of a username, or from an address,
+// that is locked out after too many failed logins.
+type LoginLockedError struct {
+	// RetryAfter is how long the lockout lasts.
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return "Too many failed logins, try again later"
+}
+
+// LockoutPolicy controls when failed logins lock out a username or a client
+// address. Each failure at or past the threshold doubles the lockout.
+type LockoutPolicy struct {
+	// MaxFailures is how many failed logins of one username lock it out.
+	MaxFailures int
+	// IPMaxFailures is how many failed logins from one address, for any
+	// username, lock the address out.
+	IPMaxFailures int
+	// BaseDuration is the first lockout, MaxDuration caps the doubling.
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+	// ResetAfter forgets the failures of a subject without a failed login
+	// for that long.
+	ResetAfter time.Duration
+}
+
+// lockout returns how long failures lock out a subject that is allowed
+// maxFailures, or 0 when they do not.
+func (p LockoutPolicy) lockout(failures int, maxFailures int) time.Duration {
+	if maxFailures <= 0 || failures < maxFailures {
+		return 0
+	}
+	d := p.BaseDuration
+	for i := maxFailures; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+	return d
+}
+
+// LoginThrottle counts failed logins per username and per client address and
+// locks them out once they fail too often. Unknown usernames are counted like
+// existing ones, so a lockout does not reveal whether an account exists.
+type LoginThrottle interface {
+	// Attempt counts a login of req as failed before its credentials are
+	// verified, and returns a *LoginLockedError without counting it while
+	// the username or the address of req is locked out. Checking and counting
+	// in one transaction keeps parallel guesses from all passing the check
+	// before any of them failed. Every attempt is resolved by Failed, Passed
+	// or Succeeded.
+	Attempt(ctx context.Context, req *models.UserLoginRequest) error
+	// Failed resolves an attempt with wrong credentials. user is nil when the
+	// username does not exist, only existing users get security events.
+	Failed(ctx context.Context, req *models.UserLoginRequest, user *models.User) error
+	// Passed resolves an attempt with right credentials that did not start a
+	// session, such as a password before the second factor. The attempt is no
+	// longer counted, earlier failures are kept.
+	Passed(ctx context.Context, req *models.UserLoginRequest) error
+	// Succeeded resolves the attempt of a login whose session started and
+	// forgets the failures of the username. Failures of the address are
+	// kept, so one valid account does not unlock an address.
+	Succeeded(ctx context.Context, req *models.UserLoginRequest) error
+}
+
+type loginThrottle struct {
+	attemptRepo repository.LoginAttemptRepository
+	txManager   repository.TxManager
+	events      SecurityEventService
+	clock       clock.Clock
+	policy      LockoutPolicy
+}
+
+func NewLoginThrottle(attemptRepo repository.LoginAttemptRepository, txManager repository.TxManager, events SecurityEventService, clk clock.Clock, policy LockoutPolicy) LoginThrottle {
+	return &loginThrottle{
+		attemptRepo: attemptRepo,
+		txManager:   txManager,
+		events:      events,
+		clock:       clk,
+		policy:      policy,
+	}
+}
+
+func (t *loginThrottle) Attempt(ctx context.Context, req *models.UserLoginRequest) error {
+	now := t.clock.Now().UTC()
+	subjects := loginSubjects(req)
+
+	return t.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// The counters stay locked until they are counted. They are created
+		// first, as a lock on a row that does not exist yet keeps nobody from
+		// creating it
+		attempts := make([]*models.LoginAttempt, len(subjects))
+		var retryAfter time.Duration
+		for i, subject := range subjects {
+			if err := t.attemptRepo.Ensure(ctx, subject, now); err != nil {
+				return err
+			}
+			attempt, err := t.attemptRepo.Find(ctx, subject)
+			if err != nil {
+				return err
+			}
+			if attempt == nil {
+				return errors.New("login attempt counter is missing")
+			}
+			if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+				if d := attempt.LockedUntil.Sub(now); d > retryAfter {
+					retryAfter = d
+				}
+			}
+			attempts[i] = attempt
+		}
+		if retryAfter > 0 {
+			return &LoginLockedError{RetryAfter: retryAfter}
+		}
+
+		for i, subject := range subjects {
+			maxFailures := t.policy.MaxFailures
+			if strings.HasPrefix(subject, ipSubjectPrefix) {
+				maxFailures = t.policy.IPMaxFailures
+			}
+
+			d, err := t.fail(ctx, attempts[i], maxFailures, now)
+			if err != nil {
+				return err
+			}
+			if d > 0 {
+				logger.FromContext(ctx).WithFields(logrus.Fields{"subject": subject, "locked_for": d.String()}).Warn("Login locked out after too many failures")
+			}
+		}
+		return nil
+	})
+}
+
+func (t *loginThrottle) Failed(ctx context.Context, req *models.UserLoginRequest, user *models.User) error {
+	if user == nil {
+		return nil
+	}
+	if err := t.record(ctx, user.Username, models.SecurityEventLoginFailed, req); err != nil {
+		return err
+	}
+
+	// The failure was counted by Attempt, which also locked the username
+	// out when it was one too many
+	attempt, err := t.attemptRepo.Find(ctx, usernameSubject(req.Username))
+	if err != nil {
+		return err
+	}
+	if attempt != nil && attempt.LockedUntil != nil && attempt.LockedUntil.After(t.clock.Now().UTC()) {
+		return t.record(ctx, user.Username, models.SecurityEventAccountLocked, req)
+	}
+	return nil
+}
+
+func (t *loginThrottle) Passed(ctx context.Context, req *models.UserLoginRequest) error {
+	return t.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		for _, subject := range loginSubjects(req) {
+			if err := t.takeBack(ctx, subject); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (t *loginThrottle) Succeeded(ctx context.Context, req *models.UserLoginRequest) error {
+	return t.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		for _, subject := range loginSubjects(req) {
+			if subject == usernameSubject(req.Username) {
+				if err := t.attemptRepo.Delete(ctx, subject); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := t.takeBack(ctx, subject); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// fail counts a failure on attempt and returns the lockout it caused.
+func (t *loginThrottle) fail(ctx context.Context, attempt *models.LoginAttempt, maxFailures int, now time.Time) (time.Duration, error) {
+	if !attempt.LastFailedAt.After(now.Add(-t.policy.ResetAfter)) {
+		attempt.Failures = 0
+		attempt.LockedUntil = nil
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+
+	d := t.policy.lockout(attempt.Failures, maxFailures)
+	if d > 0 {
+		lockedUntil := now.Add(d)
+		attempt.LockedUntil = &lockedUntil
+	}
+
+	return d, t.attemptRepo.Update(ctx, attempt)
+}
+
+// takeBack uncounts an attempt of subject that did not fail. A lockout is
+// lifted as well: attempts made while it lasted were rejected without being
+// counted, so it was set by the latest counted attempt, and with one attempt
+// less the failures no longer reach it.
+func (t *loginThrottle) takeBack(ctx context.Context, subject string) error {
+	attempt, err := t.attemptRepo.Find(ctx, subject)
+	if err != nil || attempt == nil {
+		return err
+	}
+
+	if attempt.Failures > 0 {
+		attempt.Failures--
+	}
+	attempt.LockedUntil = nil
+	return t.attemptRepo.Update(ctx, attempt)
+}
+
+func (t *loginThrottle) record(ctx context.Context, username string, eventType string, req *models.UserLoginRequest) error {
+	return t.events.Record(ctx, &models.SecurityEvent{
+		Username:  username,
+		Type:      eventType,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	})
+}
+
+const (
+	usernameSubjectPrefix = "user:"
+	ipSubjectPrefix       = "ip:"
+)
+
+// usernameSubject is case-insensitive, like the username column.
+func usernameSubject(username string) string {
+	return usernameSubjectPrefix + strings.ToLower(username)
+}
+
+// loginSubjects returns the counters a login is checked against.
+func loginSubjects(req *models.UserLoginRequest) []string {
+	subjects := []string{usernameSubject(req.Username)}
+	if req.IPAddress != "" {
+		subjects = append(subjects, ipSubjectPrefix+req.IPAddress)
+	}
+	return subjects
+}
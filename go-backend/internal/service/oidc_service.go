@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/metrics"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// oidcHTTPTimeout bounds every request to the identity provider.
+const oidcHTTPTimeout = 10 * time.Second
+
+var (
+	// ErrInvalidOIDCState is returned by Callback for unknown, used and
+	// expired states.
+	ErrInvalidOIDCState = errors.New("Invalid or expired login state")
+	// ErrOIDCLoginFailed is returned by Callback when the identity provider
+	// does not confirm the login. The cause is logged, not returned.
+	ErrOIDCLoginFailed = errors.New("Login with the identity provider failed")
+	// ErrNoLinkedAccount is returned by Callback when the identity is not
+	// linked to a user and no user has its verified email address.
+	ErrNoLinkedAccount = errors.New("No account with a verified email address matches the identity")
+	// ErrOIDCUnavailable is returned while the discovery document of the
+	// identity provider cannot be fetched.
+	ErrOIDCUnavailable = errors.New("Identity provider is unavailable")
+)
+
+// OIDCPolicy configures the OpenID Connect client.
+type OIDCPolicy struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// StateTTL is how long a user may take to log in at the identity
+	// provider.
+	StateTTL time.Duration
+}
+
+// idTokenClaims are the claims of an ID token besides the ones the verifier
+// checks.
+type idTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// OIDCService logs users in through an OpenID Connect provider with the
+// authorization code flow and PKCE. The first login of an identity links it
+// to the user with the same email address, which both the provider and this
+// service have to have verified. Later logins find the user by the link, so
+// changing the address at the provider does not move the identity.
+type OIDCService interface {
+	// Authorize starts a login and returns the address of the provider's
+	// login page.
+	Authorize(ctx context.Context, req *models.OIDCAuthorizeRequest) (*models.OIDCAuthorizeResponse, error)
+	// Callback finishes a login with the code the provider redirected back
+	// with and starts a session like a password login does.
+	Callback(ctx context.Context, req *models.OIDCCallbackRequest) (*models.LoginResponse, error)
+}
+
+type oidcService struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	stateRepo    repository.OIDCStateRepository
+	txManager    repository.TxManager
+	starter      SessionStarter
+	twoFactor    TwoFactorService
+	events       SecurityEventService
+	clock        clock.Clock
+	policy       OIDCPolicy
+	metrics      *metrics.Metrics
+	client       *http.Client
+
+	// mu guards provider and verifier, which are discovered at the first
+	// login so the service starts while the provider is unreachable.
+	mu       sync.Mutex
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewOIDCService(userRepo repository.UserRepository, identityRepo repository.UserIdentityRepository, stateRepo repository.OIDCStateRepository, txManager repository.TxManager, starter SessionStarter, twoFactor TwoFactorService, events SecurityEventService, clk clock.Clock, policy OIDCPolicy, metrics *metrics.Metrics) OIDCService {
+	return &oidcService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		txManager:    txManager,
+		starter:      starter,
+		twoFactor:    twoFactor,
+		events:       events,
+		clock:        clk,
+		policy:       policy,
+		metrics:      metrics,
+		client:       &http.Client{Timeout: oidcHTTPTimeout},
+	}
+}
+
+func (s *oidcService) Authorize(ctx context.Context, req *models.OIDCAuthorizeRequest) (*models.OIDCAuthorizeResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	provider, _, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := s.clock.Now().UTC()
+	if err := s.stateRepo.DeleteExpired(ctx, now); err != nil {
+		return nil, err
+	}
+	err = s.stateRepo.Create(ctx, &models.OIDCState{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		DeviceName:   req.DeviceName,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.policy.StateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OIDCAuthorizeResponse{
+		AuthorizationURL: s.oauth2Config(provider).AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)),
+		State:            state,
+	}, nil
+}
+
+func (s *oidcService) Callback(ctx context.Context, req *models.OIDCCallbackRequest) (*models.LoginResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	provider, verifier, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state is consumed before the code is exchanged, so a replayed
+	// callback is rejected even when the exchange fails
+	var state *models.OIDCState
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		stateHash := utils.HashToken(req.State)
+		var err error
+		state, err = s.stateRepo.FindByHash(ctx, stateHash)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return ErrInvalidOIDCState
+		}
+		if err := s.stateRepo.Delete(ctx, stateHash); err != nil {
+			return err
+		}
+		if !state.ExpiresAt.After(s.clock.Now().UTC()) {
+			return ErrInvalidOIDCState
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.oauth2Config(provider).Exchange(oidc.ClientContext(ctx, s.client), req.Code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		return nil, s.failed(ctx, "Failed to exchange the authorization code", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, s.failed(ctx, "Token response has no ID token", nil)
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, s.failed(ctx, "Failed to verify the ID token", err)
+	}
+	if idToken.Nonce != state.Nonce {
+		return nil, s.failed(ctx, "ID token nonce does not match", nil)
+	}
+	var claims idTokenClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, s.failed(ctx, "Failed to decode the ID token claims", err)
+	}
+
+	loginReq := &models.UserLoginRequest{
+		DeviceName: state.DeviceName,
+		UserAgent:  req.UserAgent,
+		IPAddress:  req.IPAddress,
+	}
+	user, err := s.linkedUser(ctx, idToken, &claims, loginReq)
+	if err != nil {
+		return nil, err
+	}
+	loginReq.Username = user.Username
+	if user.DisabledAt != nil {
+		logger.FromContext(ctx).WithField("login_username", user.Username).Info("OIDC login rejected, account disabled")
+		return nil, ErrUserDisabled
+	}
+
+	// The provider replaces the password, a second factor is still asked
+	// for when the user has one
+	res, err := s.twoFactor.Challenge(ctx, user.Username, loginReq)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		logger.FromContext(ctx).WithField("login_username", user.Username).Info("OIDC login needs a second factor")
+		return res, nil
+	}
+
+	res, err = s.starter.Start(ctx, user, loginReq)
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.LoginSucceeded()
+	logger.FromContext(ctx).WithField("login_username", user.Username).Info("OIDC login succeeded")
+	return res, nil
+}
+
+// linkedUser returns the user the identity of idToken is linked to. An
+// identity that is not linked yet is linked to the user with the email
+// address of the token when both sides verified it.
+func (s *oidcService) linkedUser(ctx context.Context, idToken *oidc.IDToken, claims *idTokenClaims, req *models.UserLoginRequest) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		identity, err := s.identityRepo.Find(ctx, idToken.Issuer, idToken.Subject)
+		if err != nil {
+			return err
+		}
+		if identity != nil {
+			user, err = s.userRepo.FindByUsername(ctx, identity.Username)
+			if err != nil {
+				return err
+			}
+			if user == nil {
+				return ErrNoLinkedAccount
+			}
+			return nil
+		}
+
+		if claims.Email == "" || !claims.EmailVerified {
+			return ErrNoLinkedAccount
+		}
+		user, err = s.userRepo.FindByEmail(ctx, claims.Email)
+		if err != nil {
+			return err
+		}
+		if user == nil || user.EmailVerifiedAt == nil {
+			return ErrNoLinkedAccount
+		}
+
+		err = s.identityRepo.Create(ctx, &models.UserIdentity{
+			Issuer:    idToken.Issuer,
+			Subject:   idToken.Subject,
+			Username:  user.Username,
+			Email:     claims.Email,
+			CreatedAt: s.clock.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, &models.SecurityEvent{
+			Username:  user.Username,
+			Type:      models.SecurityEventIdentityLinked,
+			IPAddress: truncate(req.IPAddress, 45),
+			UserAgent: truncate(req.UserAgent, 255),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoLinkedAccount) {
+			s.metrics.LoginFailed()
+			logger.FromContext(ctx).WithFields(logrus.Fields{"issuer": idToken.Issuer, "subject": idToken.Subject}).Info("OIDC login failed, no linked account")
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// discover returns the provider and the verifier of its ID tokens. A failed
+// discovery is retried at the next call.
+func (s *oidcService) discover(ctx context.Context) (*oidc.Provider, *oidc.IDTokenVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider == nil {
+		provider, err := oidc.NewProvider(oidc.ClientContext(ctx, s.client), s.policy.IssuerURL)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Failed to discover the OIDC provider")
+			return nil, nil, ErrOIDCUnavailable
+		}
+		s.provider = provider
+		s.verifier = provider.Verifier(&oidc.Config{
+			ClientID: s.policy.ClientID,
+			Now:      s.clock.Now,
+		})
+	}
+
+	return s.provider, s.verifier, nil
+}
+
+func (s *oidcService) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.policy.ClientID,
+		ClientSecret: s.policy.ClientSecret,
+		RedirectURL:  s.policy.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       s.policy.Scopes,
+	}
+}
+
+// failed logs why the provider did not confirm a login and returns
+// ErrOIDCLoginFailed.
+func (s *oidcService) failed(ctx context.Context, msg string, err error) error {
+	s.metrics.LoginFailed()
+	entry := logger.FromContext(ctx)
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn(msg)
+	return ErrOIDCLoginFailed
+}
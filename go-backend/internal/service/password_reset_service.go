@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/mail"
+	"go-backend/internal/models"
+	"go-backend/internal/password"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// resetMailTimeout bounds the delivery of a reset mail, which continues after
+// the request was answered.
+const resetMailTimeout = 30 * time.Second
+
+// PasswordResetService lets users who forgot their password set a new one
+// through a link mailed to the address of their account.
+type PasswordResetService interface {
+	// Request mails a reset link when req.Email belongs to an account. It
+	// answers the same whether or not it does, so it cannot be used to find
+	// out which addresses are registered.
+	Request(ctx context.Context, req *models.PasswordForgotRequest) error
+	// Reset sets a new password with the token of a reset mail, ends every
+	// session of the user and revokes its API keys.
+	Reset(ctx context.Context, req *models.PasswordResetRequest) error
+}
+
+type passwordResetService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	apiKeyRepo  repository.APIKeyRepository
+	txManager   repository.TxManager
+	passwords   PasswordService
+	events      SecurityEventService
+	mailer      mail.Mailer
+	hasher      *password.Hasher
+	tokens      emailTokens
+}
+
+func NewPasswordResetService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, apiKeyRepo repository.APIKeyRepository, tokenRepo repository.EmailTokenRepository, txManager repository.TxManager, passwords PasswordService, events SecurityEventService, mailer mail.Mailer, hasher *password.Hasher, clk clock.Clock, policy EmailPolicy) PasswordResetService {
+	return &passwordResetService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		apiKeyRepo:  apiKeyRepo,
+		txManager:   txManager,
+		passwords:   passwords,
+		events:      events,
+		mailer:      mailer,
+		hasher:      hasher,
+		tokens: emailTokens{
+			repo:   tokenRepo,
+			clock:  clk,
+			policy: policy,
+		},
+	}
+}
+
+func (s *passwordResetService) Request(ctx context.Context, req *models.PasswordForgotRequest) error {
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		logger.FromContext(ctx).Info("Password reset requested for an unknown email address")
+		return nil
+	}
+
+	token, err := s.tokens.issue(ctx, user, models.EmailTokenReset, s.tokens.policy.ResetTTL)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		logger.FromContext(ctx).WithField("reset_username", user.Username).Info("Password reset mail skipped, one was sent recently")
+		return nil
+	}
+
+	msg := &mail.Message{
+		To:      *user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nsomeone asked to reset the password of your account. To choose a new password, open this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not ask for it, you can ignore this mail and keep your current password.\n",
+			user.Name, s.tokens.policy.link("/reset-password", token), formatDuration(s.tokens.policy.ResetTTL)),
+	}
+
+	// The mail is delivered in the background, waiting for the mail server
+	// would make requests for registered addresses answer slower
+	log := logger.FromContext(ctx).WithField("reset_username", user.Username)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetMailTimeout)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.WithError(err).Warn("Failed to send password reset mail")
+			return
+		}
+		log.Info("Password reset mail sent")
+	}()
+
+	return nil
+}
+
+func (s *passwordResetService) Reset(ctx context.Context, req *models.PasswordResetRequest) error {
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	// The new password is checked and hashed before the token is locked, the
+	// transaction makes sure the token is still valid and used once
+	stored, err := s.tokens.repo.FindByHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.Purpose != models.EmailTokenReset {
+		return ErrInvalidEmailToken
+	}
+	if err := s.passwords.Validate(ctx, stored.Username, req.NewPassword); err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	var user *models.User
+	var revoked, revokedKeys int
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.tokens.redeem(ctx, s.userRepo, req.Token, models.EmailTokenReset)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.UpdatePassword(ctx, user.Username, hash); err != nil {
+			return err
+		}
+		if err := s.passwords.Remember(ctx, user.Username, hash); err != nil {
+			return err
+		}
+
+		// The old password may be known to someone else, every session ends.
+		// Keys minted with it would outlive the sessions, so they go too
+		revoked, err = s.sessionRepo.DeleteOthers(ctx, user.Username, "")
+		if err != nil {
+			return err
+		}
+		revokedKeys, err = s.apiKeyRepo.DeleteByUsername(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+
+		// Following the link proved control of the address
+		if user.EmailVerifiedAt == nil {
+			if err := s.userRepo.MarkEmailVerified(ctx, user.Username, s.tokens.clock.Now().UTC()); err != nil {
+				return err
+			}
+		}
+
+		return s.events.Record(ctx, &models.SecurityEvent{
+			Username:  user.Username,
+			Type:      models.SecurityEventPasswordReset,
+			IPAddress: req.IPAddress,
+			UserAgent: req.UserAgent,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"reset_username": user.Username, "revoked": revoked, "revoked_api_keys": revokedKeys}).Info("Password reset")
+	return nil
+}
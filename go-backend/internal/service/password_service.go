@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/password"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"strings"
+)
+
+// PasswordPolicyError lists every rule a new password breaks.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return strings.Join(e.Violations, ", ")
+}
+
+// PasswordPolicy extends the rules of password.Policy with checks that need
+// stored data.
+type PasswordPolicy struct {
+	password.Policy
+	// HistorySize is how many of the latest passwords of a user may not be
+	// reused, 0 keeps no history.
+	HistorySize int
+	// Breached is nil when passwords are not checked for breaches.
+	Breached password.Corpus
+}
+
+// PasswordService checks new passwords against the policy, keeps the
+// password history it needs and changes passwords.
+type PasswordService interface {
+	// Validate returns a *PasswordPolicyError when newPassword breaks a rule
+	// of the policy for username.
+	Validate(ctx context.Context, username string, newPassword string) error
+	// Remember adds hash, the new password of username, to its history.
+	Remember(ctx context.Context, username string, hash string) error
+	// Change sets a new password after verifying the current one and revokes
+	// every session of username except currentSessionID.
+	Change(ctx context.Context, username string, currentSessionID string, req *models.PasswordChangeRequest) (*models.RevokeSessionsResponse, error)
+}
+
+type passwordService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	historyRepo repository.PasswordHistoryRepository
+	txManager   repository.TxManager
+	events      SecurityEventService
+	throttle    LoginThrottle
+	hasher      *password.Hasher
+	clock       clock.Clock
+	policy      PasswordPolicy
+}
+
+func NewPasswordService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, historyRepo repository.PasswordHistoryRepository, txManager repository.TxManager, events SecurityEventService, throttle LoginThrottle, hasher *password.Hasher, clk clock.Clock, policy PasswordPolicy) PasswordService {
+	return &passwordService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		historyRepo: historyRepo,
+		txManager:   txManager,
+		events:      events,
+		throttle:    throttle,
+		hasher:      hasher,
+		clock:       clk,
+		policy:      policy,
+	}
+}
+
+func (s *passwordService) Validate(ctx context.Context, username string, newPassword string) error {
+	violations := s.policy.Violations(newPassword, username)
+
+	if s.policy.HistorySize > 0 {
+		entries, err := s.historyRepo.FindRecent(ctx, username, s.policy.HistorySize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if s.hasher.Verify(newPassword, entry.PasswordHash) {
+				violations = append(violations, fmt.Sprintf("Password must not be one of the last %d passwords", s.policy.HistorySize))
+				break
+			}
+		}
+	}
+
+	if s.policy.Breached != nil {
+		breached, err := password.Breached(s.policy.Breached, newPassword)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, "Password appears in a list of breached passwords")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (s *passwordService) Remember(ctx context.Context, username string, hash string) error {
+	if s.policy.HistorySize <= 0 {
+		return nil
+	}
+
+	err := s.historyRepo.Create(ctx, &models.PasswordHistoryEntry{
+		Username:     username,
+		PasswordHash: hash,
+		CreatedAt:    s.clock.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.historyRepo.Prune(ctx, username, s.policy.HistorySize)
+}
+
+func (s *passwordService) Change(ctx context.Context, username string, currentSessionID string, req *models.PasswordChangeRequest) (*models.RevokeSessionsResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user is not found")
+	}
+
+	// The current password is guessed at the pace of the login lockout at
+	// most, like at login
+	login := &models.UserLoginRequest{
+		Username:  user.Username,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	}
+	if err := s.throttle.Attempt(ctx, login); err != nil {
+		return nil, err
+	}
+	if !s.hasher.Verify(req.CurrentPassword, user.Password) {
+		logger.FromContext(ctx).Info("Password change failed, wrong current password")
+		if err := s.throttle.Failed(ctx, login, user); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("Current password is wrong")
+	}
+	if err := s.throttle.Passed(ctx, login); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validate(ctx, user.Username, req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	hash, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	var revoked int
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdatePassword(ctx, user.Username, hash); err != nil {
+			return err
+		}
+		if err := s.Remember(ctx, user.Username, hash); err != nil {
+			return err
+		}
+
+		// Whoever knew the old password may hold a session, only the one
+		// making the change stays logged in
+		var err error
+		revoked, err = s.sessionRepo.DeleteOthers(ctx, user.Username, currentSessionID)
+		if err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, &models.SecurityEvent{
+			Username:  user.Username,
+			Type:      models.SecurityEventPasswordChanged,
+			IPAddress: req.IPAddress,
+			UserAgent: req.UserAgent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithField("revoked", revoked).Info("Password changed")
+	return &models.RevokeSessionsResponse{
+		Revoked: revoked,
+	}, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"regexp"
+)
+
+// ErrRoleNotFound is returned for roles that do not exist.
+var ErrRoleNotFound = errors.New("Role is not found")
+
+// roleNamePattern keeps role names usable in URLs and query parameters.
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// UserAuthorizer completes the principal of a request with the role of its
+// user. Disabled users are rejected with ErrUserDisabled and users that no
+// longer exist with ErrInvalidSession.
+type UserAuthorizer interface {
+	Authorize(ctx context.Context, principal *auth.Principal) error
+}
+
+// RoleService manages the roles that grant users permissions. The built-in
+// roles cannot be changed or deleted, and roles that are assigned to users
+// cannot be deleted either.
+type RoleService interface {
+	UserAuthorizer
+	List(ctx context.Context) ([]models.Role, error)
+	Create(ctx context.Context, req *models.RoleCreateRequest) (*models.Role, error)
+	Update(ctx context.Context, name string, req *models.RoleUpdateRequest) (*models.Role, error)
+	Delete(ctx context.Context, name string) error
+}
+
+type roleService struct {
+	roleRepo  repository.RoleRepository
+	userRepo  repository.UserRepository
+	txManager repository.TxManager
+	clock     clock.Clock
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository, txManager repository.TxManager, clk clock.Clock) RoleService {
+	return &roleService{
+		roleRepo:  roleRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
+		clock:     clk,
+	}
+}
+
+func (s *roleService) Authorize(ctx context.Context, principal *auth.Principal) error {
+	user, err := s.userRepo.FindByUsername(ctx, principal.Username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidSession
+	}
+	if user.DisabledAt != nil {
+		return ErrUserDisabled
+	}
+
+	role, err := s.roleRepo.FindByName(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+
+	principal.Role = user.Role
+	if role != nil {
+		principal.Permissions = rolePermissions(role)
+	}
+	return nil
+}
+
+func (s *roleService) List(ctx context.Context) ([]models.Role, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range roles {
+		completeRole(&roles[i])
+	}
+	return roles, nil
+}
+
+func (s *roleService) Create(ctx context.Context, req *models.RoleCreateRequest) (*models.Role, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, errors.New("Role name must start with a lowercase letter and contain only lowercase letters, digits, - and _")
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		existing, err := s.roleRepo.FindByName(ctx, req.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.New("Role already exists")
+		}
+
+		return s.roleRepo.Create(ctx, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithField("role", role.Name).Info("Role created")
+	completeRole(role)
+	return role, nil
+}
+
+func (s *roleService) Update(ctx context.Context, name string, req *models.RoleUpdateRequest) (*models.Role, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	if builtInRole(name) {
+		return nil, errors.New("Built-in roles cannot be changed")
+	}
+
+	var role *models.Role
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		role, err = s.roleRepo.FindByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+
+		if req.Description != nil {
+			role.Description = *req.Description
+		}
+		if req.Permissions != nil {
+			role.Permissions, err = normalizePermissions(*req.Permissions)
+			if err != nil {
+				return err
+			}
+		}
+		role.UpdatedAt = s.clock.Now().UTC()
+
+		return s.roleRepo.Update(ctx, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Permissions are looked up on every request, so the change applies to
+	// every user of the role at once
+	logger.FromContext(ctx).WithField("role", role.Name).Info("Role updated")
+	completeRole(role)
+	return role, nil
+}
+
+func (s *roleService) Delete(ctx context.Context, name string) error {
+	if builtInRole(name) {
+		return errors.New("Built-in roles cannot be deleted")
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		role, err := s.roleRepo.FindByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+
+		count, err := s.userRepo.CountByRole(ctx, name)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("Role is assigned to users")
+		}
+
+		return s.roleRepo.Delete(ctx, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithField("role", name).Info("Role deleted")
+	return nil
+}
+
+func builtInRole(name string) bool {
+	return name == models.RoleUser || name == models.RoleAdmin
+}
+
+// rolePermissions returns the permissions role grants. The admin role grants
+// every permission, including ones added after it was stored.
+func rolePermissions(role *models.Role) []string {
+	if role.Name == models.RoleAdmin {
+		return auth.AllPermissions()
+	}
+	return role.Permissions
+}
+
+// completeRole fills in the fields of role that are not stored.
+func completeRole(role *models.Role) {
+	role.BuiltIn = builtInRole(role.Name)
+	role.Permissions = rolePermissions(role)
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+}
+
+// normalizePermissions rejects unknown permissions and returns the others
+// without duplicates, in the order of auth.AllPermissions.
+func normalizePermissions(permissions []string) ([]string, error) {
+	requested := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		if !auth.ValidPermission(permission) {
+			return nil, errors.New("Unknown permission " + permission)
+		}
+		requested[permission] = true
+	}
+
+	normalized := []string{}
+	for _, permission := range auth.AllPermissions() {
+		if requested[permission] {
+			normalized = append(normalized, permission)
+		}
+	}
+	return normalized, nil
+}
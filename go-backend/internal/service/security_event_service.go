@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"go-backend/internal/clock"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// securityEventListLimit is how many of the latest events List returns.
+const securityEventListLimit = 100
+
+// SecurityEventService records events of an account, like failed logins and
+// lockouts, that its owner can review.
+type SecurityEventService interface {
+	// Record stores event, its ID and CreatedAt are set by Record.
+	Record(ctx context.Context, event *models.SecurityEvent) error
+	List(ctx context.Context, username string) ([]models.SecurityEvent, error)
+}
+
+type securityEventService struct {
+	eventRepo repository.SecurityEventRepository
+	clock     clock.Clock
+}
+
+func NewSecurityEventService(eventRepo repository.SecurityEventRepository, clk clock.Clock) SecurityEventService {
+	return &securityEventService{
+		eventRepo: eventRepo,
+		clock:     clk,
+	}
+}
+
+func (s *securityEventService) Record(ctx context.Context, event *models.SecurityEvent) error {
+	event.ID = uuid.New().String()
+	event.CreatedAt = s.clock.Now().UTC()
+	event.UserAgent = truncate(event.UserAgent, 255)
+	event.IPAddress = truncate(event.IPAddress, 45)
+	return s.eventRepo.Create(ctx, event)
+}
+
+func (s *securityEventService) List(ctx context.Context, username string) ([]models.SecurityEvent, error) {
+	events, err := s.eventRepo.FindByUsername(ctx, username, securityEventListLimit)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []models.SecurityEvent{}
+	}
+
+	return events, nil
+}
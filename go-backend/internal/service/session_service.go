@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidSession is returned by Authenticate for unknown or expired
+// tokens.
+var ErrInvalidSession = errors.New("Unauthorized")
+
+// ErrUserDisabled is returned for logins and requests of users an admin
+// disabled.
+var ErrUserDisabled = errors.New("Account is disabled")
+
+// SessionPolicy controls how long sessions live.
+type SessionPolicy struct {
+	// TTL is the absolute lifetime of a session from login.
+	TTL time.Duration
+	// IdleTimeout ends a session that has not been used for this long, zero
+	// disables it.
+	IdleTimeout time.Duration
+	// RenewInterval is how stale the recorded last use of a session may get
+	// before a request writes it again. It bounds the writes caused by
+	// authenticated requests to one per session and interval.
+	RenewInterval time.Duration
+	// RequireVerifiedEmail limits the sessions of accounts with an
+	// unverified email address to managing the account.
+	RequireVerifiedEmail bool
+}
+
+// idleSince returns the last use before which a session is idle at now.
+func (p SessionPolicy) idleSince(now time.Time) time.Time {
+	if p.IdleTimeout <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.IdleTimeout)
+}
+
+// expiresAt returns when session expires if it is not used again.
+func (p SessionPolicy) expiresAt(session *models.Session) time.Time {
+	if p.IdleTimeout <= 0 {
+		return session.ExpiresAt
+	}
+	if idle := session.LastUsedAt.Add(p.IdleTimeout); idle.Before(session.ExpiresAt) {
+		return idle
+	}
+	return session.ExpiresAt
+}
+
+// newSession returns a session for a login of user at now.
+func (p SessionPolicy) newSession(user *models.User, req *models.UserLoginRequest, now time.Time) *models.Session {
+	return &models.Session{
+		ID:         uuid.New().String(),
+		Username:   user.Username,
+		DeviceName: req.DeviceName,
+		UserAgent:  truncate(req.UserAgent, 255),
+		IPAddress:  truncate(req.IPAddress, 45),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(p.TTL),
+		Scopes:     p.scopes(user),
+	}
+}
+
+// scopes returns the scopes of the sessions of user. With
+// RequireVerifiedEmail an account can only be managed, not used, until its
+// email address is verified. Accounts without an address, like those
+// registered before addresses were collected, keep every scope.
+func (p SessionPolicy) scopes(user *models.User) []string {
+	if p.RequireVerifiedEmail && user.Email != nil && user.EmailVerifiedAt == nil {
+		return []string{auth.ScopeUserRead, auth.ScopeUserWrite, auth.ScopeUserAdmin}
+	}
+	return auth.AllScopes()
+}
+
+// SessionStarter starts a session for a user whose credentials were checked
+// and returns the tokens the client continues it with. Disabled users are
+// rejected with ErrUserDisabled.
+type SessionStarter interface {
+	Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error)
+}
+
+// Authenticator resolves the credential sent with a request to the principal
+// it belongs to. Invalid credentials are reported as ErrInvalidSession.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+type SessionService interface {
+	// Start creates a session identified by an opaque session token.
+	Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error)
+	// Authenticate resolves a session token to the principal it belongs to
+	// and records the session as used.
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+	List(ctx context.Context, username string, currentID string) ([]models.SessionResponse, error)
+	Revoke(ctx context.Context, username string, id string) error
+	// RevokeOthers revokes every session of username except currentID.
+	RevokeOthers(ctx context.Context, username string, currentID string) (*models.RevokeSessionsResponse, error)
+}
+
+type sessionService struct {
+	sessionRepo repository.SessionRepository
+	clock       clock.Clock
+	policy      SessionPolicy
+}
+
+func NewSessionService(sessionRepo repository.SessionRepository, clk clock.Clock, policy SessionPolicy) SessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+		clock:       clk,
+		policy:      policy,
+	}
+}
+
+func (s *sessionService) Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrUserDisabled
+	}
+
+	now := s.clock.Now().UTC()
+	if err := s.sessionRepo.DeleteExpired(ctx, user.Username, now, s.policy.idleSince(now)); err != nil {
+		return nil, err
+	}
+
+	// Only the hash of the token is stored, the token itself is returned once
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenHash := utils.HashToken(token)
+
+	// Every login starts its own session, so other devices stay logged in
+	session := s.policy.newSession(user, req, now)
+	session.TokenHash = &tokenHash
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	logger.AddFields(ctx, logrus.Fields{"session_id": session.ID})
+
+	return &models.LoginResponse{
+		Token: token,
+	}, nil
+}
+
+func (s *sessionService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	session, err := s.sessionRepo.FindByTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrInvalidSession
+	}
+
+	now := s.clock.Now().UTC()
+	if !s.policy.expiresAt(session).After(now) {
+		if err := s.sessionRepo.Delete(ctx, session.ID, session.Username); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidSession
+	}
+
+	// Sliding renewal: the idle timeout counts from the recorded last use,
+	// which is only refreshed once it is RenewInterval old.
+	if now.Sub(session.LastUsedAt) >= s.policy.RenewInterval {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &auth.Principal{
+		Username:  session.Username,
+		SessionID: session.ID,
+		Scopes:    session.Scopes,
+		Method:    auth.MethodToken,
+	}, nil
+}
+
+func (s *sessionService) List(ctx context.Context, username string, currentID string) ([]models.SessionResponse, error) {
+	now := s.clock.Now().UTC()
+	sessions, err := s.sessionRepo.FindByUsername(ctx, username, now, s.policy.idleSince(now))
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, models.SessionResponse{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  s.policy.expiresAt(&session),
+			Current:    session.ID == currentID,
+		})
+	}
+
+	return responses, nil
+}
+
+func (s *sessionService) Revoke(ctx context.Context, username string, id string) error {
+	session, err := s.sessionRepo.FindByID(ctx, id, username)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errors.New("session is not found")
+	}
+
+	if err := s.sessionRepo.Delete(ctx, id, username); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithField("session_id", id).Info("Session revoked")
+	return nil
+}
+
+func (s *sessionService) RevokeOthers(ctx context.Context, username string, currentID string) (*models.RevokeSessionsResponse, error) {
+	revoked, err := s.sessionRepo.DeleteOthers(ctx, username, currentID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithField("revoked", revoked).Info("Other sessions revoked")
+	return &models.RevokeSessionsResponse{
+		Revoked: revoked,
+	}, nil
+}
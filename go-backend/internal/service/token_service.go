@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-backend/internal/auth"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/models"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidRefreshToken is returned by Refresh for unknown, expired and
+// reused refresh tokens.
+var ErrInvalidRefreshToken = errors.New("Invalid refresh token")
+
+// TokenPolicy controls the tokens issued in the jwt auth mode.
+type TokenPolicy struct {
+	// Issuer is the iss claim of access tokens, tokens of other issuers are
+	// rejected.
+	Issuer string
+	// AccessTTL is the lifetime of access tokens. A revoked session stays
+	// usable until its last access token expired.
+	AccessTTL time.Duration
+	// RefreshTTL is the lifetime of refresh tokens, it never extends past
+	// the end of the session.
+	RefreshTTL time.Duration
+}
+
+// accessClaims are the claims of an access token.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid"`
+	Scope     string `json:"scope"`
+}
+
+// TokenService continues sessions with short-lived signed access tokens,
+// which are verified without a database lookup, and opaque refresh tokens.
+// Every refresh token can be used once: using it returns a new one, and a
+// token that is used again revokes the session it belongs to, which ends
+// every token derived from the same login.
+type TokenService interface {
+	Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error)
+	Refresh(ctx context.Context, req *models.RefreshRequest) (*models.LoginResponse, error)
+	// Authenticate verifies an access token.
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+type tokenService struct {
+	sessionRepo repository.SessionRepository
+	refreshRepo repository.RefreshTokenRepository
+	txManager   repository.TxManager
+	keys        *auth.KeySet
+	clock       clock.Clock
+	sessions    SessionPolicy
+	policy      TokenPolicy
+}
+
+func NewTokenService(sessionRepo repository.SessionRepository, refreshRepo repository.RefreshTokenRepository, txManager repository.TxManager, keys *auth.KeySet, clk clock.Clock, sessions SessionPolicy, policy TokenPolicy) TokenService {
+	return &tokenService{
+		sessionRepo: sessionRepo,
+		refreshRepo: refreshRepo,
+		txManager:   txManager,
+		keys:        keys,
+		clock:       clk,
+		sessions:    sessions,
+		policy:      policy,
+	}
+}
+
+func (s *tokenService) Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrUserDisabled
+	}
+
+	now := s.clock.Now().UTC()
+	if err := s.sessionRepo.DeleteExpired(ctx, user.Username, now, s.sessions.idleSince(now)); err != nil {
+		return nil, err
+	}
+
+	session := s.sessions.newSession(user, req, now)
+
+	var res *models.LoginResponse
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.Create(ctx, session); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = s.issue(ctx, session, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.AddFields(ctx, logrus.Fields{"session_id": session.ID})
+
+	return res, nil
+}
+
+func (s *tokenService) Refresh(ctx context.Context, req *models.RefreshRequest) (*models.LoginResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+
+	// reused is set when the token was used before, the session is revoked
+	// after the transaction, which is rolled back.
+	var reused *models.RefreshToken
+	var session *models.Session
+	var res *models.LoginResponse
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		token, err := s.refreshRepo.FindByHash(ctx, utils.HashToken(req.RefreshToken))
+		if err != nil {
+			return err
+		}
+		if token == nil {
+			return ErrInvalidRefreshToken
+		}
+		if token.UsedAt != nil {
+			reused = token
+			return ErrInvalidRefreshToken
+		}
+		if !token.ExpiresAt.After(now) {
+			return ErrInvalidRefreshToken
+		}
+
+		session, err = s.sessionRepo.FindByID(ctx, token.SessionID, token.Username)
+		if err != nil {
+			return err
+		}
+		if session == nil || !s.sessions.expiresAt(session).After(now) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Of concurrent refreshes with the same token only one marks it used,
+		// the others count as reuse.
+		marked, err := s.refreshRepo.MarkUsed(ctx, token.TokenHash, now)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			reused = token
+			return ErrInvalidRefreshToken
+		}
+
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			return err
+		}
+		session.LastUsedAt = now
+
+		res, err = s.issue(ctx, session, now)
+		return err
+	})
+
+	if reused != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{"username": reused.Username, "session_id": reused.SessionID}).Warn("Refresh token reused, revoking session")
+		if err := s.sessionRepo.Delete(ctx, reused.SessionID, reused.Username); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.AddFields(ctx, logrus.Fields{"session_id": session.ID})
+
+	return res, nil
+}
+
+func (s *tokenService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	var claims accessClaims
+	err := s.keys.Parse(token, &claims,
+		jwt.WithIssuer(s.policy.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.clock.Now),
+	)
+	if err != nil || claims.Subject == "" {
+		return nil, ErrInvalidSession
+	}
+
+	return &auth.Principal{
+		Username:  claims.Subject,
+		SessionID: claims.SessionID,
+		Scopes:    strings.Fields(claims.Scope),
+		Method:    auth.MethodJWT,
+	}, nil
+}
+
+// issue creates a refresh token for session and signs an access token.
+func (s *tokenService) issue(ctx context.Context, session *models.Session, now time.Time) (*models.LoginResponse, error) {
+	refreshToken, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(s.policy.RefreshTTL)
+	if session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+	err = s.refreshRepo.Create(ctx, &models.RefreshToken{
+		TokenHash: utils.HashToken(refreshToken),
+		SessionID: session.ID,
+		Username:  session.Username,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.keys.Sign(accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.policy.Issuer,
+			Subject:   session.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.policy.AccessTTL)),
+		},
+		SessionID: session.ID,
+		Scope:     strings.Join(session.Scopes, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:        accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.policy.AccessTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
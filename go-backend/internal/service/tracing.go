@@ -0,0 +1,656 @@
+package service
+
+import (
+	"context"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The traced services wrap a service and run each call in a child span named
+// after the method, recording the returned error on the span.
+
+type tracedUserService struct {
+	next   UserService
+	tracer trace.Tracer
+}
+
+func NewTracedUserService(next UserService, tracer trace.Tracer) UserService {
+	return &tracedUserService{next: next, tracer: tracer}
+}
+
+func (s *tracedUserService) Register(ctx context.Context, req *models.UserRegisterRequest) (*models.UserResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.Register")
+	res, err := s.next.Register(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedUserService) Login(ctx context.Context, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.Login")
+	res, err := s.next.Login(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedUserService) GetCurrent(ctx context.Context, username string) (*models.UserResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetCurrent")
+	res, err := s.next.GetCurrent(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedUserService) Update(ctx context.Context, username string, req *models.UserUpdateRequest) (*models.UserResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.Update")
+	res, err := s.next.Update(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedUserService) Logout(ctx context.Context, username string, sessionID string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.Logout")
+	err := s.next.Logout(ctx, username, sessionID)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedContactService struct {
+	next   ContactService
+	tracer trace.Tracer
+}
+
+func NewTracedContactService(next ContactService, tracer trace.Tracer) ContactService {
+	return &tracedContactService{next: next, tracer: tracer}
+}
+
+func (s *tracedContactService) Create(ctx context.Context, username string, req *models.ContactCreateRequest) (*models.ContactResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "ContactService.Create")
+	res, err := s.next.Create(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedContactService) GetByID(ctx context.Context, id int, username string) (*models.ContactResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "ContactService.GetByID")
+	res, err := s.next.GetByID(ctx, id, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedContactService) Update(ctx context.Context, id int, username string, req *models.ContactUpdateRequest) (*models.ContactResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "ContactService.Update")
+	res, err := s.next.Update(ctx, id, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedContactService) Delete(ctx context.Context, id int, username string) error {
+	ctx, span := s.tracer.Start(ctx, "ContactService.Delete")
+	err := s.next.Delete(ctx, id, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedContactService) Search(ctx context.Context, username string, req *models.ContactSearchRequest) (*models.ContactSearchResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "ContactService.Search")
+	res, err := s.next.Search(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedAddressService struct {
+	next   AddressService
+	tracer trace.Tracer
+}
+
+func NewTracedAddressService(next AddressService, tracer trace.Tracer) AddressService {
+	return &tracedAddressService{next: next, tracer: tracer}
+}
+
+func (s *tracedAddressService) Create(ctx context.Context, contactID int, username string, req *models.AddressCreateRequest) (*models.AddressResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AddressService.Create")
+	res, err := s.next.Create(ctx, contactID, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAddressService) GetByID(ctx context.Context, id int, contactID int, username string) (*models.AddressResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AddressService.GetByID")
+	res, err := s.next.GetByID(ctx, id, contactID, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAddressService) Update(ctx context.Context, id int, contactID int, username string, req *models.AddressUpdateRequest) (*models.AddressResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AddressService.Update")
+	res, err := s.next.Update(ctx, id, contactID, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAddressService) Delete(ctx context.Context, id int, contactID int, username string) error {
+	ctx, span := s.tracer.Start(ctx, "AddressService.Delete")
+	err := s.next.Delete(ctx, id, contactID, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedAddressService) GetByContactID(ctx context.Context, contactID int, username string) ([]models.AddressResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AddressService.GetByContactID")
+	res, err := s.next.GetByContactID(ctx, contactID, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedSessionService struct {
+	next   SessionService
+	tracer trace.Tracer
+}
+
+func NewTracedSessionService(next SessionService, tracer trace.Tracer) SessionService {
+	return &tracedSessionService{next: next, tracer: tracer}
+}
+
+func (s *tracedSessionService) Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "SessionService.Start")
+	res, err := s.next.Start(ctx, user, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedSessionService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	ctx, span := s.tracer.Start(ctx, "SessionService.Authenticate")
+	res, err := s.next.Authenticate(ctx, token)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedSessionService) List(ctx context.Context, username string, currentID string) ([]models.SessionResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "SessionService.List")
+	res, err := s.next.List(ctx, username, currentID)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedSessionService) Revoke(ctx context.Context, username string, id string) error {
+	ctx, span := s.tracer.Start(ctx, "SessionService.Revoke")
+	err := s.next.Revoke(ctx, username, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedSessionService) RevokeOthers(ctx context.Context, username string, currentID string) (*models.RevokeSessionsResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "SessionService.RevokeOthers")
+	res, err := s.next.RevokeOthers(ctx, username, currentID)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedTokenService struct {
+	next   TokenService
+	tracer trace.Tracer
+}
+
+func NewTracedTokenService(next TokenService, tracer trace.Tracer) TokenService {
+	return &tracedTokenService{next: next, tracer: tracer}
+}
+
+func (s *tracedTokenService) Start(ctx context.Context, user *models.User, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TokenService.Start")
+	res, err := s.next.Start(ctx, user, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedTokenService) Refresh(ctx context.Context, req *models.RefreshRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TokenService.Refresh")
+	res, err := s.next.Refresh(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedTokenService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	ctx, span := s.tracer.Start(ctx, "TokenService.Authenticate")
+	res, err := s.next.Authenticate(ctx, token)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedAPIKeyService struct {
+	next   APIKeyService
+	tracer trace.Tracer
+}
+
+func NewTracedAPIKeyService(next APIKeyService, tracer trace.Tracer) APIKeyService {
+	return &tracedAPIKeyService{next: next, tracer: tracer}
+}
+
+func (s *tracedAPIKeyService) Create(ctx context.Context, principal *auth.Principal, req *models.APIKeyCreateRequest) (*models.APIKeyResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "APIKeyService.Create")
+	res, err := s.next.Create(ctx, principal, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAPIKeyService) List(ctx context.Context, username string) ([]models.APIKeyResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "APIKeyService.List")
+	res, err := s.next.List(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAPIKeyService) Revoke(ctx context.Context, username string, id string) error {
+	ctx, span := s.tracer.Start(ctx, "APIKeyService.Revoke")
+	err := s.next.Revoke(ctx, username, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedAPIKeyService) Authenticate(ctx context.Context, key string, ip string) (*auth.Principal, error) {
+	ctx, span := s.tracer.Start(ctx, "APIKeyService.Authenticate")
+	res, err := s.next.Authenticate(ctx, key, ip)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedTwoFactorService struct {
+	next   TwoFactorService
+	tracer trace.Tracer
+}
+
+func NewTracedTwoFactorService(next TwoFactorService, tracer trace.Tracer) TwoFactorService {
+	return &tracedTwoFactorService{next: next, tracer: tracer}
+}
+
+func (s *tracedTwoFactorService) Enroll(ctx context.Context, username string) (*models.TwoFactorEnrollResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.Enroll")
+	res, err := s.next.Enroll(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedTwoFactorService) Confirm(ctx context.Context, username string, req *models.TwoFactorCodeRequest) (*models.RecoveryCodesResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.Confirm")
+	res, err := s.next.Confirm(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedTwoFactorService) Disable(ctx context.Context, username string, req *models.TwoFactorDisableRequest) error {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.Disable")
+	err := s.next.Disable(ctx, username, req)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedTwoFactorService) Reauthenticate(ctx context.Context, login *models.UserLoginRequest, code string) error {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.Reauthenticate")
+	err := s.next.Reauthenticate(ctx, login, code)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, username string, req *models.TwoFactorCodeRequest) (*models.RecoveryCodesResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.RegenerateRecoveryCodes")
+	res, err := s.next.RegenerateRecoveryCodes(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedTwoFactorService) Challenge(ctx context.Context, username string, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.Challenge")
+	res, err := s.next.Challenge(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedTwoFactorService) CompleteLogin(ctx context.Context, req *models.TwoFactorLoginRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "TwoFactorService.CompleteLogin")
+	res, err := s.next.CompleteLogin(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedLoginThrottle struct {
+	next   LoginThrottle
+	tracer trace.Tracer
+}
+
+func NewTracedLoginThrottle(next LoginThrottle, tracer trace.Tracer) LoginThrottle {
+	return &tracedLoginThrottle{next: next, tracer: tracer}
+}
+
+func (t *tracedLoginThrottle) Attempt(ctx context.Context, req *models.UserLoginRequest) error {
+	ctx, span := t.tracer.Start(ctx, "LoginThrottle.Attempt")
+	err := t.next.Attempt(ctx, req)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedLoginThrottle) Failed(ctx context.Context, req *models.UserLoginRequest, user *models.User) error {
+	ctx, span := t.tracer.Start(ctx, "LoginThrottle.Failed")
+	err := t.next.Failed(ctx, req, user)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedLoginThrottle) Passed(ctx context.Context, req *models.UserLoginRequest) error {
+	ctx, span := t.tracer.Start(ctx, "LoginThrottle.Passed")
+	err := t.next.Passed(ctx, req)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedLoginThrottle) Succeeded(ctx context.Context, req *models.UserLoginRequest) error {
+	ctx, span := t.tracer.Start(ctx, "LoginThrottle.Succeeded")
+	err := t.next.Succeeded(ctx, req)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedSecurityEventService struct {
+	next   SecurityEventService
+	tracer trace.Tracer
+}
+
+func NewTracedSecurityEventService(next SecurityEventService, tracer trace.Tracer) SecurityEventService {
+	return &tracedSecurityEventService{next: next, tracer: tracer}
+}
+
+func (s *tracedSecurityEventService) Record(ctx context.Context, event *models.SecurityEvent) error {
+	ctx, span := s.tracer.Start(ctx, "SecurityEventService.Record")
+	err := s.next.Record(ctx, event)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedSecurityEventService) List(ctx context.Context, username string) ([]models.SecurityEvent, error) {
+	ctx, span := s.tracer.Start(ctx, "SecurityEventService.List")
+	res, err := s.next.List(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedPasswordService struct {
+	next   PasswordService
+	tracer trace.Tracer
+}
+
+func NewTracedPasswordService(next PasswordService, tracer trace.Tracer) PasswordService {
+	return &tracedPasswordService{next: next, tracer: tracer}
+}
+
+func (s *tracedPasswordService) Validate(ctx context.Context, username string, newPassword string) error {
+	ctx, span := s.tracer.Start(ctx, "PasswordService.Validate")
+	err := s.next.Validate(ctx, username, newPassword)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedPasswordService) Change(ctx context.Context, username string, currentSessionID string, req *models.PasswordChangeRequest) (*models.RevokeSessionsResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "PasswordService.Change")
+	res, err := s.next.Change(ctx, username, currentSessionID, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedPasswordService) Remember(ctx context.Context, username string, hash string) error {
+	ctx, span := s.tracer.Start(ctx, "PasswordService.Remember")
+	err := s.next.Remember(ctx, username, hash)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedEmailVerificationService struct {
+	next   EmailVerificationService
+	tracer trace.Tracer
+}
+
+func NewTracedEmailVerificationService(next EmailVerificationService, tracer trace.Tracer) EmailVerificationService {
+	return &tracedEmailVerificationService{next: next, tracer: tracer}
+}
+
+func (s *tracedEmailVerificationService) Send(ctx context.Context, username string) error {
+	ctx, span := s.tracer.Start(ctx, "EmailVerificationService.Send")
+	err := s.next.Send(ctx, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedEmailVerificationService) Verify(ctx context.Context, req *models.EmailVerifyRequest) error {
+	ctx, span := s.tracer.Start(ctx, "EmailVerificationService.Verify")
+	err := s.next.Verify(ctx, req)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedPasswordResetService struct {
+	next   PasswordResetService
+	tracer trace.Tracer
+}
+
+func NewTracedPasswordResetService(next PasswordResetService, tracer trace.Tracer) PasswordResetService {
+	return &tracedPasswordResetService{next: next, tracer: tracer}
+}
+
+func (s *tracedPasswordResetService) Request(ctx context.Context, req *models.PasswordForgotRequest) error {
+	ctx, span := s.tracer.Start(ctx, "PasswordResetService.Request")
+	err := s.next.Request(ctx, req)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedPasswordResetService) Reset(ctx context.Context, req *models.PasswordResetRequest) error {
+	ctx, span := s.tracer.Start(ctx, "PasswordResetService.Reset")
+	err := s.next.Reset(ctx, req)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedOIDCService struct {
+	next   OIDCService
+	tracer trace.Tracer
+}
+
+func NewTracedOIDCService(next OIDCService, tracer trace.Tracer) OIDCService {
+	return &tracedOIDCService{next: next, tracer: tracer}
+}
+
+func (s *tracedOIDCService) Authorize(ctx context.Context, req *models.OIDCAuthorizeRequest) (*models.OIDCAuthorizeResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "OIDCService.Authorize")
+	res, err := s.next.Authorize(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedOIDCService) Callback(ctx context.Context, req *models.OIDCCallbackRequest) (*models.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "OIDCService.Callback")
+	res, err := s.next.Callback(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedRoleService struct {
+	next   RoleService
+	tracer trace.Tracer
+}
+
+func NewTracedRoleService(next RoleService, tracer trace.Tracer) RoleService {
+	return &tracedRoleService{next: next, tracer: tracer}
+}
+
+func (s *tracedRoleService) Authorize(ctx context.Context, principal *auth.Principal) error {
+	ctx, span := s.tracer.Start(ctx, "RoleService.Authorize")
+	err := s.next.Authorize(ctx, principal)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedRoleService) List(ctx context.Context) ([]models.Role, error) {
+	ctx, span := s.tracer.Start(ctx, "RoleService.List")
+	res, err := s.next.List(ctx)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedRoleService) Create(ctx context.Context, req *models.RoleCreateRequest) (*models.Role, error) {
+	ctx, span := s.tracer.Start(ctx, "RoleService.Create")
+	res, err := s.next.Create(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedRoleService) Update(ctx context.Context, name string, req *models.RoleUpdateRequest) (*models.Role, error) {
+	ctx, span := s.tracer.Start(ctx, "RoleService.Update")
+	res, err := s.next.Update(ctx, name, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedRoleService) Delete(ctx context.Context, name string) error {
+	ctx, span := s.tracer.Start(ctx, "RoleService.Delete")
+	err := s.next.Delete(ctx, name)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedAdminService struct {
+	next   AdminService
+	tracer trace.Tracer
+}
+
+func NewTracedAdminService(next AdminService, tracer trace.Tracer) AdminService {
+	return &tracedAdminService{next: next, tracer: tracer}
+}
+
+func (s *tracedAdminService) SearchUsers(ctx context.Context, req *models.AdminUserSearchRequest) (*models.AdminUserSearchResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AdminService.SearchUsers")
+	res, err := s.next.SearchUsers(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAdminService) GetUser(ctx context.Context, username string) (*models.AdminUserResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AdminService.GetUser")
+	res, err := s.next.GetUser(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAdminService) Disable(ctx context.Context, admin *models.AdminRequest, username string) error {
+	ctx, span := s.tracer.Start(ctx, "AdminService.Disable")
+	err := s.next.Disable(ctx, admin, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedAdminService) Enable(ctx context.Context, admin *models.AdminRequest, username string) error {
+	ctx, span := s.tracer.Start(ctx, "AdminService.Enable")
+	err := s.next.Enable(ctx, admin, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedAdminService) Logout(ctx context.Context, admin *models.AdminRequest, username string) (*models.RevokeSessionsResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AdminService.Logout")
+	res, err := s.next.Logout(ctx, admin, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAdminService) SetPassword(ctx context.Context, admin *models.AdminRequest, username string, req *models.AdminPasswordRequest) (*models.RevokeSessionsResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AdminService.SetPassword")
+	res, err := s.next.SetPassword(ctx, admin, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAdminService) SetRole(ctx context.Context, admin *models.AdminRequest, username string, req *models.AdminRoleRequest) (*models.AdminUserResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AdminService.SetRole")
+	res, err := s.next.SetRole(ctx, admin, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedAccountDeletionService struct {
+	next   AccountDeletionService
+	tracer trace.Tracer
+}
+
+func NewTracedAccountDeletionService(next AccountDeletionService, tracer trace.Tracer) AccountDeletionService {
+	return &tracedAccountDeletionService{next: next, tracer: tracer}
+}
+
+func (s *tracedAccountDeletionService) Schedule(ctx context.Context, username string, req *models.AccountDeleteRequest) (*models.AccountDeletionResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AccountDeletionService.Schedule")
+	res, err := s.next.Schedule(ctx, username, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedAccountDeletionService) Cancel(ctx context.Context, username string, userAgent string, ipAddress string) error {
+	ctx, span := s.tracer.Start(ctx, "AccountDeletionService.Cancel")
+	err := s.next.Cancel(ctx, username, userAgent, ipAddress)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedAccountDeletionService) PurgeDue(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "AccountDeletionService.PurgeDue")
+	res, err := s.next.PurgeDue(ctx)
+	tracing.End(span, err)
+	return res, err
+}
+
+type tracedDataExportService struct {
+	next   DataExportService
+	tracer trace.Tracer
+}
+
+func NewTracedDataExportService(next DataExportService, tracer trace.Tracer) DataExportService {
+	return &tracedDataExportService{next: next, tracer: tracer}
+}
+
+func (s *tracedDataExportService) Request(ctx context.Context, username string) (*models.DataExportResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "DataExportService.Request")
+	res, err := s.next.Request(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedDataExportService) Open(ctx context.Context, id string, expires string, signature string) (*DataExportArchive, error) {
+	ctx, span := s.tracer.Start(ctx, "DataExportService.Open")
+	res, err := s.next.Open(ctx, id, expires, signature)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedDataExportService) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "DataExportService.PurgeExpired")
+	res, err := s.next.PurgeExpired(ctx)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedDataExportService) DeleteByUsername(ctx context.Context, username string) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "DataExportService.DeleteByUsername")
+	res, err := s.next.DeleteByUsername(ctx, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *tracedDataExportService) RemoveArchives(ctx context.Context, ids []string) error {
+	ctx, span := s.tracer.Start(ctx, "DataExportService.RemoveArchives")
+	err := s.next.RemoveArchives(ctx, ids)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedDataExportService) Shutdown(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "DataExportService.Shutdown")
+	err := s.next.Shutdown(ctx)
+	tracing.End(span, err)
+	return err
+}
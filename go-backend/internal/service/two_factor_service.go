@@ -0,0 +1,584 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"go-backend/internal/clock"
+	"go-backend/internal/logger"
+	"go-backend/internal/metrics"
+	"go-backend/internal/models"
+	"go-backend/internal/password"
+	"go-backend/internal/repository"
+	"go-backend/internal/utils"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidChallenge is returned by CompleteLogin for unknown and expired
+// challenge tokens and for challenges with too many wrong codes.
+var ErrInvalidChallenge = errors.New("Invalid or expired challenge")
+
+var (
+	errInvalidCode   = errors.New("Invalid code")
+	errWrongPassword = errors.New("Password is wrong")
+)
+
+// TOTP parameters understood by every common authenticator app.
+const (
+	totpPeriod = 30
+	totpDigits = otp.DigitsSix
+	// totpSkew is how many time steps a code may be off, to allow for clock
+	// drift of the device.
+	totpSkew = 1
+)
+
+const (
+	recoveryCodeCount = 10
+	// recoveryCodeBytes makes an 80 bit code, shown as four groups of four
+	// base32 characters.
+	recoveryCodeBytes = 10
+)
+
+// TwoFactorPolicy controls the second login step.
+type TwoFactorPolicy struct {
+	// Issuer names the service in authenticator apps.
+	Issuer string
+	// ChallengeTTL is how long the second step may take after the password
+	// was verified.
+	ChallengeTTL time.Duration
+	// MaxAttempts is how many wrong codes end a challenge.
+	MaxAttempts int
+}
+
+type TwoFactorService interface {
+	// Enroll starts setting up TOTP for username and returns the secret. It
+	// is only enabled once Confirm verified a code generated from it.
+	Enroll(ctx context.Context, username string) (*models.TwoFactorEnrollResponse, error)
+	Confirm(ctx context.Context, username string, req *models.TwoFactorCodeRequest) (*models.RecoveryCodesResponse, error)
+	// Disable turns two-factor authentication off after verifying the
+	// password and a TOTP or recovery code.
+	Disable(ctx context.Context, username string, req *models.TwoFactorDisableRequest) error
+	// Reauthenticate verifies a TOTP or recovery code of the user of login
+	// before a sensitive change. It accepts any code when the user has not
+	// enabled two-factor authentication.
+	Reauthenticate(ctx context.Context, login *models.UserLoginRequest, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes after verifying a
+	// TOTP code.
+	RegenerateRecoveryCodes(ctx context.Context, username string, req *models.TwoFactorCodeRequest) (*models.RecoveryCodesResponse, error)
+	// Challenge returns the challenge for a login whose password was
+	// verified, or nil when username has not enabled two-factor
+	// authentication.
+	Challenge(ctx context.Context, username string, req *models.UserLoginRequest) (*models.LoginResponse, error)
+	// CompleteLogin verifies the code of a challenge and starts the session.
+	CompleteLogin(ctx context.Context, req *models.TwoFactorLoginRequest) (*models.LoginResponse, error)
+}
+
+type twoFactorService struct {
+	userRepo      repository.UserRepository
+	totpRepo      repository.TOTPRepository
+	challengeRepo repository.LoginChallengeRepository
+	txManager     repository.TxManager
+	starter       SessionStarter
+	throttle      LoginThrottle
+	hasher        *password.Hasher
+	clock         clock.Clock
+	policy        TwoFactorPolicy
+	metrics       *metrics.Metrics
+}
+
+func NewTwoFactorService(userRepo repository.UserRepository, totpRepo repository.TOTPRepository, challengeRepo repository.LoginChallengeRepository, txManager repository.TxManager, starter SessionStarter, throttle LoginThrottle, hasher *password.Hasher, clk clock.Clock, policy TwoFactorPolicy, metrics *metrics.Metrics) TwoFactorService {
+	return &twoFactorService{
+		userRepo:      userRepo,
+		totpRepo:      totpRepo,
+		challengeRepo: challengeRepo,
+		txManager:     txManager,
+		starter:       starter,
+		throttle:      throttle,
+		hasher:        hasher,
+		clock:         clk,
+		policy:        policy,
+		metrics:       metrics,
+	}
+}
+
+func (s *twoFactorService) Enroll(ctx context.Context, username string) (*models.TwoFactorEnrollResponse, error) {
+	credential, err := s.totpRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if credential != nil && credential.ConfirmedAt != nil {
+		return nil, errors.New("Two-factor authentication is already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.policy.Issuer,
+		AccountName: username,
+		Period:      totpPeriod,
+		Digits:      totpDigits,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrolling again replaces an unconfirmed secret
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.totpRepo.Delete(ctx, username); err != nil {
+			return err
+		}
+		return s.totpRepo.Create(ctx, &models.TOTPCredential{
+			Username:  username,
+			Secret:    key.Secret(),
+			CreatedAt: s.clock.Now().UTC(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qrCode, err := qrCodePNG(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollResponse{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: qrCode,
+	}, nil
+}
+
+func (s *twoFactorService) Confirm(ctx context.Context, username string, req *models.TwoFactorCodeRequest) (*models.RecoveryCodesResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		credential, err := s.totpRepo.FindByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if credential == nil {
+			return errors.New("Two-factor authentication is not being set up")
+		}
+		if credential.ConfirmedAt != nil {
+			return errors.New("Two-factor authentication is already enabled")
+		}
+
+		now := s.clock.Now().UTC()
+		if ok, err := s.verifyTOTP(ctx, credential, req.Code, now); err != nil || !ok {
+			return firstErr(err, errInvalidCode)
+		}
+		if err := s.totpRepo.Confirm(ctx, username, now); err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(ctx, username, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Two-factor authentication enabled")
+	return &models.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, username string, req *models.TwoFactorDisableRequest) error {
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	login := &models.UserLoginRequest{Username: username, UserAgent: req.UserAgent, IPAddress: req.IPAddress}
+	err := s.throttled(ctx, login, func(ctx context.Context) error {
+		return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			user, err := s.userRepo.FindByUsername(ctx, username)
+			if err != nil {
+				return err
+			}
+			if user == nil || !s.hasher.Verify(req.Password, user.Password) {
+				return errWrongPassword
+			}
+			credential, err := s.enabledCredential(ctx, username)
+			if err != nil {
+				return err
+			}
+
+			if ok, _, err := s.verifyCode(ctx, credential, req.Code); err != nil || !ok {
+				return firstErr(err, errInvalidCode)
+			}
+
+			if err := s.totpRepo.DeleteRecoveryCodes(ctx, username); err != nil {
+				return err
+			}
+			return s.totpRepo.Delete(ctx, username)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Two-factor authentication disabled")
+	return nil
+}
+
+func (s *twoFactorService) Reauthenticate(ctx context.Context, login *models.UserLoginRequest, code string) error {
+	credential, err := s.totpRepo.FindByUsername(ctx, login.Username)
+	if err != nil {
+		return err
+	}
+	if credential == nil || credential.ConfirmedAt == nil {
+		return nil
+	}
+	if code == "" {
+		return errors.New("Two-factor code is required")
+	}
+
+	return s.throttled(ctx, login, func(ctx context.Context) error {
+		return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			credential, err := s.enabledCredential(ctx, login.Username)
+			if err != nil {
+				return err
+			}
+
+			if ok, _, err := s.verifyCode(ctx, credential, code); err != nil || !ok {
+				return firstErr(err, errInvalidCode)
+			}
+			return nil
+		})
+	})
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, username string, req *models.TwoFactorCodeRequest) (*models.RecoveryCodesResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	login := &models.UserLoginRequest{Username: username, UserAgent: req.UserAgent, IPAddress: req.IPAddress}
+	err := s.throttled(ctx, login, func(ctx context.Context) error {
+		return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			credential, err := s.enabledCredential(ctx, username)
+			if err != nil {
+				return err
+			}
+
+			now := s.clock.Now().UTC()
+			if ok, err := s.verifyTOTP(ctx, credential, req.Code, now); err != nil || !ok {
+				return firstErr(err, errInvalidCode)
+			}
+
+			codes, err = s.replaceRecoveryCodes(ctx, username, now)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Recovery codes regenerated")
+	return &models.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *twoFactorService) Challenge(ctx context.Context, username string, req *models.UserLoginRequest) (*models.LoginResponse, error) {
+	credential, err := s.totpRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || credential.ConfirmedAt == nil {
+		return nil, nil
+	}
+
+	now := s.clock.Now().UTC()
+	if err := s.challengeRepo.DeleteExpired(ctx, username, now); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.challengeRepo.Create(ctx, &models.LoginChallenge{
+		TokenHash:  utils.HashToken(token),
+		Username:   username,
+		DeviceName: req.DeviceName,
+		UserAgent:  truncate(req.UserAgent, 255),
+		IPAddress:  truncate(req.IPAddress, 45),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.policy.ChallengeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+	}, nil
+}
+
+func (s *twoFactorService) CompleteLogin(ctx context.Context, req *models.TwoFactorLoginRequest) (*models.LoginResponse, error) {
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	tokenHash := utils.HashToken(req.ChallengeToken)
+	var challenge *models.LoginChallenge
+	var method string
+	var wrongCode bool
+	// The challenge stays locked while its code is verified and counted, so
+	// parallel requests cannot try more than MaxAttempts codes
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		challenge, err = s.challengeRepo.FindByHash(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+		if challenge == nil || !challenge.ExpiresAt.After(s.clock.Now().UTC()) || challenge.Attempts >= s.policy.MaxAttempts {
+			return ErrInvalidChallenge
+		}
+
+		// Wrong codes count against the username like wrong passwords, a
+		// locked out username cannot go on guessing with open challenges
+		if err := s.throttle.Attempt(ctx, s.loginRequest(challenge, req)); err != nil {
+			return err
+		}
+
+		credential, err := s.totpRepo.FindByUsername(ctx, challenge.Username)
+		if err != nil {
+			return err
+		}
+		if credential == nil || credential.ConfirmedAt == nil {
+			// Two-factor authentication was disabled in the meantime
+			return ErrInvalidChallenge
+		}
+
+		var ok bool
+		ok, method, err = s.verifyCode(ctx, credential, req.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// The attempt is committed, the error is returned below
+			wrongCode = true
+			if challenge.Attempts+1 >= s.policy.MaxAttempts {
+				return s.challengeRepo.Delete(ctx, tokenHash)
+			}
+			return s.challengeRepo.AddAttempt(ctx, tokenHash)
+		}
+
+		return s.challengeRepo.Delete(ctx, tokenHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, challenge.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if wrongCode {
+		s.metrics.LoginFailed()
+		logger.FromContext(ctx).WithField("login_username", challenge.Username).Info("Login failed, wrong two-factor code")
+		if err := s.throttle.Failed(ctx, s.loginRequest(challenge, req), user); err != nil {
+			return nil, err
+		}
+		return nil, errInvalidCode
+	}
+
+	if user == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	res, err := s.starter.Start(ctx, user, &models.UserLoginRequest{
+		Username:   challenge.Username,
+		DeviceName: challenge.DeviceName,
+		UserAgent:  challenge.UserAgent,
+		IPAddress:  challenge.IPAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.throttle.Succeeded(ctx, s.loginRequest(challenge, req)); err != nil {
+		return nil, err
+	}
+
+	s.metrics.LoginSucceeded()
+	logger.FromContext(ctx).WithFields(logrus.Fields{"login_username": challenge.Username, "second_factor": method}).Info("Login succeeded")
+	return res, nil
+}
+
+// throttled runs verify, which checks a code or password of login.Username
+// for a signed in user, as a login attempt. Wrong ones count as failed logins
+// and a locked out username cannot try more, so a stolen session or token
+// cannot guess codes faster than a login could.
+func (s *twoFactorService) throttled(ctx context.Context, login *models.UserLoginRequest, verify func(ctx context.Context) error) error {
+	if err := s.throttle.Attempt(ctx, login); err != nil {
+		return err
+	}
+
+	err := verify(ctx)
+	if errors.Is(err, errInvalidCode) || errors.Is(err, errWrongPassword) {
+		user, findErr := s.userRepo.FindByUsername(ctx, login.Username)
+		if findErr != nil {
+			return findErr
+		}
+		logger.FromContext(ctx).WithError(err).Info("Reauthentication failed")
+		if failErr := s.throttle.Failed(ctx, login, user); failErr != nil {
+			return failErr
+		}
+		return err
+	}
+
+	// Anything else, like a code that was right, was no guess
+	if passErr := s.throttle.Passed(ctx, login); passErr != nil {
+		return passErr
+	}
+	return err
+}
+
+// loginRequest returns the login of challenge as seen by the login throttle,
+// from the address that sent the code.
+func (s *twoFactorService) loginRequest(challenge *models.LoginChallenge, req *models.TwoFactorLoginRequest) *models.UserLoginRequest {
+	return &models.UserLoginRequest{
+		Username:  challenge.Username,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	}
+}
+
+func (s *twoFactorService) enabledCredential(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	credential, err := s.totpRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || credential.ConfirmedAt == nil {
+		return nil, errors.New("Two-factor authentication is not enabled")
+	}
+	return credential, nil
+}
+
+// verifyCode accepts a TOTP code or uses up a recovery code, and returns
+// which of the two was given.
+func (s *twoFactorService) verifyCode(ctx context.Context, credential *models.TOTPCredential, code string) (bool, string, error) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) == int(totpDigits) {
+		ok, err := s.verifyTOTP(ctx, credential, code, s.clock.Now().UTC())
+		return ok, "totp", err
+	}
+
+	ok, err := s.totpRepo.UseRecoveryCode(ctx, credential.Username, utils.HashToken(normalizeRecoveryCode(code)))
+	if ok {
+		logger.FromContext(ctx).Info("Recovery code used")
+	}
+	return ok, "recovery_code", err
+}
+
+// verifyTOTP checks code against the time steps around now. The step of an
+// accepted code is recorded, so every code is accepted only once.
+func (s *twoFactorService) verifyTOTP(ctx context.Context, credential *models.TOTPCredential, code string, now time.Time) (bool, error) {
+	code = strings.ReplaceAll(code, " ", "")
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totp.GenerateCodeCustom(credential.Secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    totpDigits,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return s.totpRepo.UseStep(ctx, credential.Username, step)
+		}
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes stores new recovery codes for username and returns
+// them. Only their hashes are kept.
+func (s *twoFactorService) replaceRecoveryCodes(ctx context.Context, username string, now time.Time) ([]string, error) {
+	if err := s.totpRepo.DeleteRecoveryCodes(ctx, username); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			CodeHash:  utils.HashToken(normalizeRecoveryCode(code)),
+			Username:  username,
+			CreatedAt: now,
+		})
+	}
+
+	if err := s.totpRepo.CreateRecoveryCodes(ctx, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code like ABCD-EFGH-IJKL-MNOP.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base32.StdEncoding.EncodeToString(b)
+
+	groups := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		groups = append(groups, raw[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// normalizeRecoveryCode makes codes typed in lower case or without dashes
+// match.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// qrCodePNG renders the otpauth URI of key as a PNG data URL.
+func qrCodePNG(key *otp.Key) (string, error) {
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// firstErr returns err, or fallback when err is nil.
+func firstErr(err error, fallback error) error {
+	if err != nil {
+		return err
+	}
+	return fallback
+}
@@ -1,39 +1,63 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"go-backend/internal/logger"
+	"go-backend/internal/metrics"
 	"go-backend/internal/models"
+	"go-backend/internal/password"
 	"go-backend/internal/repository"
 	"go-backend/internal/utils"
-
-	"github.com/google/uuid"
+	"unicode/utf8"
 )
 
 type UserService interface {
-	Register(req *models.UserRegisterRequest) (*models.UserResponse, error)
-	Login(req *models.UserLoginRequest) (*models.LoginResponse, error)
-	GetCurrent(username string) (*models.UserResponse, error)
-	Update(username string, req *models.UserUpdateRequest) (*models.UserResponse, error)
-	Logout(username string) error
+	Register(ctx context.Context, req *models.UserRegisterRequest) (*models.UserResponse, error)
+	Login(ctx context.Context, req *models.UserLoginRequest) (*models.LoginResponse, error)
+	GetCurrent(ctx context.Context, username string) (*models.UserResponse, error)
+	Update(ctx context.Context, username string, req *models.UserUpdateRequest) (*models.UserResponse, error)
+	Logout(ctx context.Context, username string, sessionID string) error
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	txManager   repository.TxManager
+	starter     SessionStarter
+	twoFactor   TwoFactorService
+	throttle    LoginThrottle
+	passwords   PasswordService
+	verifier    EmailVerificationService
+	hasher      *password.Hasher
+	metrics     *metrics.Metrics
+	// requireEmail rejects registrations without an email address.
+	requireEmail bool
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, txManager repository.TxManager, starter SessionStarter, twoFactor TwoFactorService, throttle LoginThrottle, passwords PasswordService, verifier EmailVerificationService, hasher *password.Hasher, metrics *metrics.Metrics, requireEmail bool) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		txManager:    txManager,
+		starter:      starter,
+		twoFactor:    twoFactor,
+		throttle:     throttle,
+		passwords:    passwords,
+		verifier:     verifier,
+		hasher:       hasher,
+		metrics:      metrics,
+		requireEmail: requireEmail,
 	}
 }
 
-func (s *userService) Register(req *models.UserRegisterRequest) (*models.UserResponse, error) {
+func (s *userService) Register(ctx context.Context, req *models.UserRegisterRequest) (*models.UserResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
 
 	// Check if username already exists
-	count, err := s.userRepo.CountByUsername(req.Username)
+	count, err := s.userRepo.CountByUsername(ctx, req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -41,8 +65,26 @@ func (s *userService) Register(req *models.UserRegisterRequest) (*models.UserRes
 		return nil, errors.New("Username already exists")
 	}
 
+	var email *string
+	if req.Email != "" {
+		existing, err := s.userRepo.FindByEmail(ctx, req.Email)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, errors.New("Email already exists")
+		}
+		email = &req.Email
+	} else if s.requireEmail {
+		return nil, errors.New("Email is required")
+	}
+
+	if err := s.passwords.Validate(ctx, req.Username, req.Password); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := utils.HashPassword(req.Password)
+	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -51,50 +93,117 @@ func (s *userService) Register(req *models.UserRegisterRequest) (*models.UserRes
 		Username: req.Username,
 		Password: hashedPassword,
 		Name:     req.Name,
+		Email:    email,
+		Role:     models.RoleUser,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.passwords.Remember(ctx, user.Username, user.Password)
+	})
+	if err != nil {
 		return nil, err
 	}
+	logger.FromContext(ctx).WithField("new_username", user.Username).Info("User registered")
 
-	return &models.UserResponse{
-		Username: user.Username,
-		Name:     user.Name,
-	}, nil
+	// The account exists either way, a verification mail that could not be
+	// sent can be requested again after logging in
+	if email != nil {
+		if err := s.verifier.Send(ctx, user.Username); err != nil {
+			logger.FromContext(ctx).WithError(err).Warn("Failed to send verification mail")
+		}
+	}
+
+	return toUserResponse(user), nil
 }
 
-func (s *userService) Login(req *models.UserLoginRequest) (*models.LoginResponse, error) {
+func (s *userService) Login(ctx context.Context, req *models.UserLoginRequest) (*models.LoginResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
 
-	user, err := s.userRepo.FindByUsername(req.Username)
+	// A locked out login is rejected before the password is checked, so
+	// guessing goes on at the pace of the lockout at most
+	if err := s.throttle.Attempt(ctx, req); err != nil {
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			s.metrics.LoginLocked()
+			logger.FromContext(ctx).WithField("login_username", req.Username).Info("Login rejected, locked out")
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, req.Username)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		// Spend the time of a password check so unknown usernames do not
+		// answer faster
+		s.hasher.VerifyDummy(req.Password)
+		s.metrics.LoginFailed()
+		logger.FromContext(ctx).WithField("login_username", req.Username).Info("Login failed, unknown username")
+		if err := s.throttle.Failed(ctx, req, nil); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("Username or password wrong")
 	}
 
-	if !utils.CheckPasswordHash(req.Password, user.Password) {
+	if !s.hasher.Verify(req.Password, user.Password) {
+		s.metrics.LoginFailed()
+		logger.FromContext(ctx).WithField("login_username", req.Username).Info("Login failed, wrong password")
+		if err := s.throttle.Failed(ctx, req, user); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("Username or password wrong")
 	}
 
-	// Generate token
-	token := uuid.New().String()
-	user.Token = &token
+	// The password is only known now, so hashes of an older cost are
+	// upgraded at login
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehash(ctx, user.Username, req.Password)
+	}
+
+	if user.DisabledAt != nil {
+		logger.FromContext(ctx).WithField("login_username", user.Username).Info("Login rejected, account disabled")
+		if err := s.throttle.Passed(ctx, req); err != nil {
+			return nil, err
+		}
+		return nil, ErrUserDisabled
+	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	// With two-factor authentication the session is only started once
+	// the code was verified, the failures of the username are only
+	// forgotten then
+	res, err := s.twoFactor.Challenge(ctx, user.Username, req)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		if err := s.throttle.Passed(ctx, req); err != nil {
+			return nil, err
+		}
+		logger.FromContext(ctx).WithField("login_username", user.Username).Info("Login needs a second factor")
+		return res, nil
+	}
+
+	res, err = s.starter.Start(ctx, user, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.throttle.Succeeded(ctx, req); err != nil {
 		return nil, err
 	}
 
-	return &models.LoginResponse{
-		Token: token,
-	}, nil
+	s.metrics.LoginSucceeded()
+	logger.FromContext(ctx).WithField("login_username", user.Username).Info("Login succeeded")
+	return res, nil
 }
 
-func (s *userService) GetCurrent(username string) (*models.UserResponse, error) {
-	user, err := s.userRepo.FindByUsername(username)
+func (s *userService) GetCurrent(ctx context.Context, username string) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
@@ -102,18 +211,19 @@ func (s *userService) GetCurrent(username string) (*models.UserResponse, error)
 		return nil, errors.New("user is not found")
 	}
 
-	return &models.UserResponse{
-		Username: user.Username,
-		Name:     user.Name,
-	}, nil
+	return toUserResponse(user), nil
 }
 
-func (s *userService) Update(username string, req *models.UserUpdateRequest) (*models.UserResponse, error) {
+func (s *userService) Update(ctx context.Context, username string, req *models.UserUpdateRequest) (*models.UserResponse, error) {
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, err
 	}
+	// Changing the password needs the current one, see PasswordService
+	if req.Password != nil {
+		return nil, errors.New("Password can only be changed with POST /api/users/current/password")
+	}
 
-	user, err := s.userRepo.FindByUsername(username)
+	user, err := s.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
@@ -125,33 +235,58 @@ func (s *userService) Update(username string, req *models.UserUpdateRequest) (*m
 		user.Name = *req.Name
 	}
 
-	if req.Password != nil {
-		hashedPassword, err := utils.HashPassword(*req.Password)
-		if err != nil {
-			return nil, err
-		}
-		user.Password = hashedPassword
-	}
-
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
 
-	return &models.UserResponse{
-		Username: user.Username,
-		Name:     user.Name,
-	}, nil
+	return toUserResponse(user), nil
 }
 
-func (s *userService) Logout(username string) error {
-	user, err := s.userRepo.FindByUsername(username)
+func (s *userService) Logout(ctx context.Context, username string, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID, username)
 	if err != nil {
 		return err
 	}
-	if user == nil {
-		return errors.New("user is not found")
+	if session == nil {
+		return errors.New("session is not found")
+	}
+
+	return s.sessionRepo.Delete(ctx, sessionID, username)
+}
+
+func toUserResponse(user *models.User) *models.UserResponse {
+	return &models.UserResponse{
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Role:          user.Role,
+		DeletionDueAt: user.DeletionDueAt,
 	}
+}
 
-	user.Token = nil
-	return s.userRepo.Update(user)
+// rehash replaces the hash of username with one of the configured cost. A
+// failure leaves the old hash, which still verifies, so it does not fail the
+// login.
+func (s *userService) rehash(ctx context.Context, username string, plain string) {
+	hash, err := s.hasher.Hash(plain)
+	if err == nil {
+		err = s.userRepo.UpdatePassword(ctx, username, hash)
+	}
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Warn("Failed to upgrade password hash")
+		return
+	}
+	logger.FromContext(ctx).Info("Password hash upgraded")
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// NewClientIPContext returns a copy of ctx carrying the client address
+// resolved by TrustedProxies.ClientIP.
+func NewClientIPContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the address of the client that sent r, as resolved from
+// the forwarding headers of trusted proxies, or the address of the peer when
+// none was resolved.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+// peerIP returns the address of the peer that sent r.
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// TrustedProxies are the networks of the reverse proxies whose forwarding
+// headers are believed.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses addresses and CIDR ranges.
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) trusts(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that sent r. The hops named in
+// the Forwarded header, or in X-Forwarded-For without it, are walked from
+// the peer backwards while they are trusted proxies, and the first one that
+// is not is the client. Hops further back can be set by any client and are
+// ignored, as are the headers when the peer is not a trusted proxy.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	client := peerIP(r)
+	if len(p) == 0 {
+		return client
+	}
+
+	hops := forwardedHops(r.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(client)
+		if ip == nil || !p.trusts(ip) {
+			break
+		}
+		// A hop that is no address, like "unknown", leaves the last proxy
+		// as the client
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		client = hops[i]
+	}
+	return client
+}
+
+// forwardedHops returns the client addresses named by the Forwarded header,
+// or by X-Forwarded-For when there is none, in the order they were added.
+func forwardedHops(header http.Header) []string {
+	var hops []string
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				hops = append(hops, forwardedFor(element))
+			}
+		}
+		return hops
+	}
+
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// forwardedFor returns the address of the for parameter of one element of a
+// Forwarded header, without quotes, brackets and port.
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(key, "for") {
+			continue
+		}
+
+		value = strings.Trim(value, `"`)
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			return host
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	}
+	return ""
+}